@@ -75,6 +75,10 @@ func (c *APIClient) NewRequestWithContext(ctx context.Context, method string, ur
 }
 
 func (c *APIClient) DoContext(ctx context.Context, method string, body io.Reader, query url.Values, path ...string) (resp *http.Response, err error) {
+	return c.doContext(ctx, method, "application/json", body, query, path...)
+}
+
+func (c *APIClient) doContext(ctx context.Context, method, contentType string, body io.Reader, query url.Values, path ...string) (resp *http.Response, err error) {
 	uri := c.NewRequestURI(query, path...)
 
 	req, err := c.NewRequestWithContext(ctx, method, uri, body)
@@ -87,7 +91,7 @@ func (c *APIClient) DoContext(ctx context.Context, method string, body io.Reader
 	}
 
 	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", contentType)
 	}
 
 	return c.client.Do(req)
@@ -136,6 +140,8 @@ func (c *APIClient) PostPlan(plan *PlanMetadata) (response *PlanCreateResponse,
 		return nil, err
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		data, err := io.ReadAll(resp.Body)
 		if err != nil {
@@ -145,8 +151,6 @@ func (c *APIClient) PostPlan(plan *PlanMetadata) (response *PlanCreateResponse,
 		return nil, fmt.Errorf("request failed with data: %s", data)
 	}
 
-	defer resp.Body.Close()
-
 	decoder := json.NewDecoder(resp.Body)
 
 	response = &PlanCreateResponse{}
@@ -168,6 +172,8 @@ func (c *APIClient) DeletePlan(plan PlanMetadata) (ok bool, err error) {
 		return false, err
 	}
 
+	defer resp.Body.Close()
+
 	return resp.StatusCode >= 200 && resp.StatusCode < 300, nil
 }
 
@@ -228,3 +234,64 @@ func (c *APIClient) GetPlans(draw, start, length int, public bool, search, order
 
 	return plans, nil
 }
+
+func (c *APIClient) GetPlansAll(public bool, search string) (plans []PlanMetadata, err error) {
+	const length = 100
+
+	for start := 0; ; {
+		var page *PlanMetadataResponse
+
+		if page, err = c.GetPlans(0, start, length, public, search, ""); err != nil {
+			return nil, err
+		}
+
+		plans = append(plans, page.Data...)
+
+		start += len(page.Data)
+
+		if len(page.Data) == 0 || start >= page.RecordsFiltered {
+			return plans, nil
+		}
+	}
+}
+
+func (c *APIClient) GetPlan(ctx context.Context, id string) (plan *PlanMetadata, err error) {
+	resp, err := c.DoContext(ctx, http.MethodGet, nil, nil, "plan", id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("request for plan '%s' failed with status %d and data: %s", id, resp.StatusCode, data)
+	}
+
+	plan = &PlanMetadata{}
+
+	if err = json.NewDecoder(resp.Body).Decode(plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// ExportPlan returns the zip archive export of a plan and the logs of its test instances. The caller must close it.
+func (c *APIClient) ExportPlan(ctx context.Context, id string) (archive io.ReadCloser, err error) {
+	resp, err := c.DoContext(ctx, http.MethodGet, nil, nil, "plan", "export", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		data, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("request to export plan '%s' failed with status %d and data: %s", id, resp.StatusCode, data)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,271 @@
+package oidcc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultCertificateValidity is how long the certificates issued for the plans are valid.
+	DefaultCertificateValidity = 365 * 24 * time.Hour
+
+	clientCertificateOrganization = "go-oidcc conformance"
+)
+
+// PlanMTLS is the mtls or mtls2 config of a plan which holds the PEM encoded client certificate and private key the
+// suite presents to the token endpoint, and for a CA issued certificate the PEM encoded CA certificate.
+type PlanMTLS struct {
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+	CA   string `json:"ca,omitempty"`
+}
+
+// SelfSigned reports whether the client certificate is self-signed rather than issued by a CA.
+func (m *PlanMTLS) SelfSigned() bool {
+	return m.CA == ""
+}
+
+// Certificate returns the parsed client certificate.
+func (m *PlanMTLS) Certificate() (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(m.Cert))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("error decoding client certificate: no certificate found")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding client certificate: %w", err)
+	}
+
+	return certificate, nil
+}
+
+// CertificateAuthority is a throwaway CA which issues the client certificates of mTLS plans. The identity provider
+// must trust the CA certificate for tls_client_auth.
+type CertificateAuthority struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// NewCertificateAuthority generates a CA with a P-256 key and a self-signed certificate with the common name.
+func NewCertificateAuthority(commonName string) (ca *CertificateAuthority, err error) {
+	var key *ecdsa.PrivateKey
+
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return nil, fmt.Errorf("error generating certificate authority: %w", err)
+	}
+
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName, Organization: []string{clientCertificateOrganization}},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	var certificate *x509.Certificate
+
+	if certificate, err = createCertificate(template, nil, key.Public(), key); err != nil {
+		return nil, fmt.Errorf("error generating certificate authority: %w", err)
+	}
+
+	return &CertificateAuthority{Certificate: certificate, Key: key}, nil
+}
+
+// PEM returns the PEM encoded CA certificate.
+func (ca *CertificateAuthority) PEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate.Raw}))
+}
+
+// IssueClientCertificate generates a key and issues a client certificate for the client ID. The subject common name is
+// the client ID and the client ID is also added as a DNS SAN.
+func (ca *CertificateAuthority) IssueClientCertificate(clientID string) (mtls *PlanMTLS, err error) {
+	return newClientCertificate(clientID, ca)
+}
+
+// NewSelfSignedClientCertificate generates a key and a self-signed client certificate for the client ID for
+// self_signed_tls_client_auth.
+func NewSelfSignedClientCertificate(clientID string) (mtls *PlanMTLS, err error) {
+	return newClientCertificate(clientID, nil)
+}
+
+func newClientCertificate(clientID string, ca *CertificateAuthority) (mtls *PlanMTLS, err error) {
+	var key *ecdsa.PrivateKey
+
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return nil, fmt.Errorf("error generating client certificate for client '%s': %w", clientID, err)
+	}
+
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: clientID, Organization: []string{clientCertificateOrganization}},
+		DNSNames:              []string{clientID},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	mtls = &PlanMTLS{}
+
+	var certificate *x509.Certificate
+
+	if ca != nil {
+		certificate, err = createCertificate(template, ca.Certificate, key.Public(), ca.Key)
+		mtls.CA = ca.PEM()
+	} else {
+		certificate, err = createCertificate(template, nil, key.Public(), key)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error generating client certificate for client '%s': %w", clientID, err)
+	}
+
+	var der []byte
+
+	if der, err = x509.MarshalPKCS8PrivateKey(key); err != nil {
+		return nil, fmt.Errorf("error encoding client key for client '%s': %w", clientID, err)
+	}
+
+	mtls.Cert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+	mtls.Key = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	return mtls, nil
+}
+
+// NewSelfSignedServerCertificate generates a key and a self-signed server certificate for the hosts, which are added
+// as IP SANs or DNS SANs, and returns them PEM encoded. The identity provider must trust the certificate to fetch from
+// a server using it.
+func NewSelfSignedServerCertificate(hosts ...string) (certPEM, keyPEM string, err error) {
+	var key *ecdsa.PrivateKey
+
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return "", "", fmt.Errorf("error generating server certificate: %w", err)
+	}
+
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{clientCertificateOrganization}},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	if len(hosts) != 0 {
+		template.Subject.CommonName = hosts[0]
+	}
+
+	var certificate *x509.Certificate
+
+	if certificate, err = createCertificate(template, nil, key.Public(), key); err != nil {
+		return "", "", fmt.Errorf("error generating server certificate: %w", err)
+	}
+
+	var der []byte
+
+	if der, err = x509.MarshalPKCS8PrivateKey(key); err != nil {
+		return "", "", fmt.Errorf("error encoding server key: %w", err)
+	}
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	return certPEM, keyPEM, nil
+}
+
+// createCertificate signs the template with a random serial number and the default validity. If the parent is nil the
+// certificate is self-signed.
+func createCertificate(template, parent *x509.Certificate, public crypto.PublicKey, signer crypto.Signer) (certificate *x509.Certificate, err error) {
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	template.NotBefore, template.NotAfter = now.Add(-time.Hour), now.Add(DefaultCertificateValidity)
+
+	if parent == nil {
+		parent = template
+	}
+
+	var der []byte
+
+	if der, err = x509.CreateCertificate(rand.Reader, template, parent, public, signer); err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// ClientCertificateAuthorities returns the distinct PEM encoded CA certificates of the mTLS configs of the plans which
+// the identity provider has to trust.
+func ClientCertificateAuthorities(plans ...*PlanMetadata) (cas []string) {
+	for _, plan := range plans {
+		if plan == nil || plan.Config == nil {
+			continue
+		}
+
+		for _, mtls := range []*PlanMTLS{plan.Config.MTLS, plan.Config.MTLS2} {
+			if mtls != nil && mtls.CA != "" && !slices.Contains(cas, mtls.CA) {
+				cas = append(cas, mtls.CA)
+			}
+		}
+	}
+
+	return cas
+}
+
+// newCertificateClientJWK returns the jwks entry which registers a self-signed client certificate. The key ID is the
+// base64url encoded SHA-256 thumbprint of the certificate.
+func newCertificateClientJWK(certificate *x509.Certificate) (key ClientJWK, err error) {
+	switch public := certificate.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch public.Curve {
+		case elliptic.P256():
+			key.Algorithm = "ES256"
+		case elliptic.P384():
+			key.Algorithm = "ES384"
+		case elliptic.P521():
+			key.Algorithm = "ES512"
+		}
+	case *rsa.PublicKey:
+		key.Algorithm = "RS256"
+	}
+
+	if key.Algorithm == "" {
+		return key, fmt.Errorf("error encoding client certificate: unsupported public key")
+	}
+
+	var der []byte
+
+	if der, err = x509.MarshalPKIXPublicKey(certificate.PublicKey); err != nil {
+		return key, fmt.Errorf("error encoding client certificate: %w", err)
+	}
+
+	sum := sha256.Sum256(certificate.Raw)
+
+	key.KeyID = base64.RawURLEncoding.EncodeToString(sum[:])
+	key.Use = "sig"
+	key.Key = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	key.CertificateChain = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+
+	return key, nil
+}
@@ -0,0 +1,281 @@
+package oidcc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Client is a client registration in the identity_providers.oidc.clients section of an Authelia configuration.
+type Client struct {
+	ClientID                           string      `yaml:"client_id"`
+	ClientName                         string      `yaml:"client_name,omitempty"`
+	ClientSecret                       string      `yaml:"client_secret,omitempty"`
+	SectorIdentifierURI                string      `yaml:"sector_identifier_uri,omitempty"`
+	Public                             bool        `yaml:"public"`
+	RedirectURIs                       []string    `yaml:"redirect_uris"`
+	RequestURIs                        []string    `yaml:"request_uris,omitempty"`
+	PostLogoutRedirectURIs             []string    `yaml:"post_logout_redirect_uris,omitempty"`
+	FrontChannelLogoutURI              string      `yaml:"frontchannel_logout_uri,omitempty"`
+	FrontChannelLogoutSessionRequired  bool        `yaml:"frontchannel_logout_session_required,omitempty"`
+	BackChannelLogoutURI               string      `yaml:"backchannel_logout_uri,omitempty"`
+	BackChannelLogoutSessionRequired   bool        `yaml:"backchannel_logout_session_required,omitempty"`
+	Audience                           []string    `yaml:"audience,omitempty"`
+	Scopes                             []string    `yaml:"scopes"`
+	GrantTypes                         []string    `yaml:"grant_types"`
+	ResponseTypes                      []string    `yaml:"response_types"`
+	ResponseModes                      []string    `yaml:"response_modes"`
+	AuthorizationPolicy                string      `yaml:"authorization_policy"`
+	Lifespan                           string      `yaml:"lifespan,omitempty"`
+	RequestedAudienceMode              string      `yaml:"requested_audience_mode,omitempty"`
+	ConsentMode                        string      `yaml:"consent_mode"`
+	PreConfiguredConsentDuration       string      `yaml:"pre_configured_consent_duration,omitempty"`
+	RequirePushedAuthorizationRequests bool        `yaml:"require_pushed_authorization_requests,omitempty"`
+	RequirePKCE                        bool        `yaml:"require_pkce,omitempty"`
+	DPoPBoundAccessTokens              bool        `yaml:"dpop_bound_access_tokens,omitempty"`
+	TLSClientCertificateBoundTokens    bool        `yaml:"tls_client_certificate_bound_access_tokens,omitempty"`
+	PKCEChallengeMethod                string      `yaml:"pkce_challenge_method,omitempty"`
+	AuthorizationSignedResponseAlg     string      `yaml:"authorization_signed_response_alg,omitempty"`
+	AuthorizationSignedResponseKeyID   string      `yaml:"authorization_signed_response_key_id,omitempty"`
+	IDTokenSignedResponseAlg           string      `yaml:"id_token_signed_response_alg,omitempty"`
+	IDTokenSignedResponseKeyID         string      `yaml:"id_token_signed_response_key_id,omitempty"`
+	AccessTokenSignedResponseAlg       string      `yaml:"access_token_signed_response_alg,omitempty"`
+	AccessTokenSignedResponseKeyID     string      `yaml:"access_token_signed_response_key_id,omitempty"`
+	UserinfoSignedResponseAlg          string      `yaml:"userinfo_signed_response_alg,omitempty"`
+	UserinfoSignedResponseKeyID        string      `yaml:"userinfo_signed_response_key_id,omitempty"`
+	IntrospectionSignedResponseAlg     string      `yaml:"introspection_signed_response_alg,omitempty"`
+	IntrospectionSignedResponseKeyID   string      `yaml:"introspection_signed_response_key_id,omitempty"`
+	RequestObjectSigningAlg            string      `yaml:"request_object_signing_alg"`
+	TokenEndpointAuthMethod            string      `yaml:"token_endpoint_auth_method"`
+	TokenEndpointAuthSigningAlg        string      `yaml:"token_endpoint_auth_signing_alg,omitempty"`
+	TLSClientAuthSubjectDN             string      `yaml:"tls_client_auth_subject_dn,omitempty"`
+	AllowMultipleAuthMethods           bool        `yaml:"allow_multiple_auth_methods,omitempty"`
+	JSONWebKeysURI                     string      `yaml:"jwks_uri,omitempty"`
+	JSONWebKeys                        []ClientJWK `yaml:"jwks,omitempty"`
+}
+
+// ClientJWK is a public key registered for a client in the jwks option of an Authelia client.
+type ClientJWK struct {
+	KeyID            string `yaml:"key_id"`
+	Algorithm        string `yaml:"algorithm,omitempty"`
+	Use              string `yaml:"use,omitempty"`
+	Key              string `yaml:"key"`
+	CertificateChain string `yaml:"certificate_chain,omitempty"`
+}
+
+var (
+	clientAuthMethods         = []string{"none", "client_secret_basic", "client_secret_post", "client_secret_jwt", "private_key_jwt", "tls_client_auth", "self_signed_tls_client_auth"}
+	clientGrantTypes          = []string{"authorization_code", "implicit", "refresh_token", "client_credentials", "urn:ietf:params:oauth:grant-type:device_code"}
+	clientResponseTypes       = []string{"code", "id_token", "token", "code id_token", "code token", "id_token token", "code id_token token"}
+	clientResponseModes       = []string{"form_post", "query", "fragment", "jwt", "form_post.jwt", "query.jwt", "fragment.jwt"}
+	clientConsentModes        = []string{"auto", "explicit", "implicit", "pre-configured"}
+	clientAudienceModes       = []string{"explicit", "implicit"}
+	clientPKCEMethods         = []string{"plain", "S256"}
+	clientAsymmetricAlgs      = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+	clientSymmetricAlgs       = []string{"HS256", "HS384", "HS512"}
+	clientOptionalAsymmetrics = append([]string{"none"}, clientAsymmetricAlgs...)
+)
+
+// Validate checks the client against the values Authelia accepts for each option and the combinations of options
+// Authelia rejects. Every problem is reported.
+func (c Client) Validate() error {
+	var errs []error
+
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("client '%s': "+format, append([]any{c.ClientID}, args...)...))
+	}
+
+	oneOf := func(option, value string, valid []string) {
+		if value != "" && !slices.Contains(valid, value) {
+			invalid("option '%s' must be one of '%s' but it's configured as '%s'", option, strings.Join(valid, "', '"), value)
+		}
+	}
+
+	allOf := func(option string, values, valid []string) {
+		for _, value := range values {
+			oneOf(option, value, valid)
+		}
+	}
+
+	if c.ClientID == "" {
+		invalid("option 'client_id' is required")
+	}
+
+	if c.AuthorizationPolicy == "" {
+		invalid("option 'authorization_policy' is required")
+	}
+
+	oneOf("token_endpoint_auth_method", c.TokenEndpointAuthMethod, clientAuthMethods)
+	allOf("grant_types", c.GrantTypes, clientGrantTypes)
+	allOf("response_types", c.ResponseTypes, clientResponseTypes)
+	allOf("response_modes", c.ResponseModes, clientResponseModes)
+	oneOf("consent_mode", c.ConsentMode, clientConsentModes)
+	oneOf("requested_audience_mode", c.RequestedAudienceMode, clientAudienceModes)
+	oneOf("pkce_challenge_method", c.PKCEChallengeMethod, clientPKCEMethods)
+	oneOf("authorization_signed_response_alg", c.AuthorizationSignedResponseAlg, clientOptionalAsymmetrics)
+	oneOf("id_token_signed_response_alg", c.IDTokenSignedResponseAlg, clientAsymmetricAlgs)
+	oneOf("access_token_signed_response_alg", c.AccessTokenSignedResponseAlg, clientOptionalAsymmetrics)
+	oneOf("userinfo_signed_response_alg", c.UserinfoSignedResponseAlg, clientOptionalAsymmetrics)
+	oneOf("introspection_signed_response_alg", c.IntrospectionSignedResponseAlg, clientOptionalAsymmetrics)
+	oneOf("request_object_signing_alg", c.RequestObjectSigningAlg, clientOptionalAsymmetrics)
+
+	switch c.TokenEndpointAuthMethod {
+	case "client_secret_jwt":
+		oneOf("token_endpoint_auth_signing_alg", c.TokenEndpointAuthSigningAlg, clientSymmetricAlgs)
+	case "private_key_jwt":
+		oneOf("token_endpoint_auth_signing_alg", c.TokenEndpointAuthSigningAlg, clientAsymmetricAlgs)
+
+		if c.JSONWebKeysURI == "" && len(c.JSONWebKeys) == 0 {
+			invalid("option 'jwks_uri' or 'jwks' is required with token_endpoint_auth_method 'private_key_jwt'")
+		}
+	case "self_signed_tls_client_auth":
+		if c.JSONWebKeysURI == "" && len(c.JSONWebKeys) == 0 {
+			invalid("option 'jwks_uri' or 'jwks' is required with token_endpoint_auth_method 'self_signed_tls_client_auth'")
+		}
+
+		for i, key := range c.JSONWebKeys {
+			if key.CertificateChain == "" {
+				invalid("option 'jwks' key %d has no 'certificate_chain' which is required with token_endpoint_auth_method 'self_signed_tls_client_auth'", i+1)
+			}
+		}
+	}
+
+	if c.TokenEndpointAuthSigningAlg != "" && c.TokenEndpointAuthMethod != "client_secret_jwt" && c.TokenEndpointAuthMethod != "private_key_jwt" {
+		invalid("option 'token_endpoint_auth_signing_alg' must only be configured with token_endpoint_auth_method 'client_secret_jwt' or 'private_key_jwt'")
+	}
+
+	switch {
+	case c.TokenEndpointAuthMethod == "tls_client_auth" && c.TLSClientAuthSubjectDN == "":
+		invalid("option 'tls_client_auth_subject_dn' is required with token_endpoint_auth_method 'tls_client_auth'")
+	case c.TokenEndpointAuthMethod != "tls_client_auth" && c.TLSClientAuthSubjectDN != "":
+		invalid("option 'tls_client_auth_subject_dn' must only be configured with token_endpoint_auth_method 'tls_client_auth'")
+	}
+
+	switch {
+	case c.Public:
+		if c.ClientSecret != "" {
+			invalid("option 'client_secret' must not be configured for a public client")
+		}
+
+		if c.TokenEndpointAuthMethod != "" && c.TokenEndpointAuthMethod != "none" {
+			invalid("option 'token_endpoint_auth_method' must be 'none' for a public client but it's configured as '%s'", c.TokenEndpointAuthMethod)
+		}
+	case c.TokenEndpointAuthMethod == "none":
+		invalid("option 'token_endpoint_auth_method' must not be 'none' for a confidential client")
+	case c.ClientSecret == "" && !slices.Contains([]string{"private_key_jwt", "tls_client_auth", "self_signed_tls_client_auth"}, c.TokenEndpointAuthMethod):
+		invalid("option 'client_secret' is required for a confidential client")
+	}
+
+	for _, responseType := range c.ResponseTypes {
+		for _, grantType := range grantTypesForResponseTypes([]string{responseType}) {
+			if grantType != "refresh_token" && !slices.Contains(c.GrantTypes, grantType) {
+				invalid("option 'grant_types' must contain '%s' for the response type '%s'", grantType, responseType)
+			}
+		}
+	}
+
+	if slices.Contains(c.Scopes, "offline_access") && !slices.Contains(c.GrantTypes, "refresh_token") {
+		invalid("option 'grant_types' must contain 'refresh_token' for the scope 'offline_access'")
+	}
+
+	if len(c.RedirectURIs) == 0 && (slices.Contains(c.GrantTypes, "authorization_code") || slices.Contains(c.GrantTypes, "implicit")) {
+		invalid("option 'redirect_uris' is required for the authorization_code and implicit grants")
+	}
+
+	for _, option := range []struct {
+		name string
+		uris []string
+	}{
+		{"redirect_uris", c.RedirectURIs},
+		{"post_logout_redirect_uris", c.PostLogoutRedirectURIs},
+		{"frontchannel_logout_uri", []string{c.FrontChannelLogoutURI}},
+		{"backchannel_logout_uri", []string{c.BackChannelLogoutURI}},
+	} {
+		for _, uri := range option.uris {
+			if uri == "" {
+				continue
+			}
+
+			if u, err := url.Parse(uri); err != nil || !u.IsAbs() {
+				invalid("option '%s' has an invalid value '%s': the uri must be absolute", option.name, uri)
+			}
+		}
+	}
+
+	if c.FrontChannelLogoutSessionRequired && c.FrontChannelLogoutURI == "" {
+		invalid("option 'frontchannel_logout_session_required' must only be configured with 'frontchannel_logout_uri'")
+	}
+
+	if c.BackChannelLogoutSessionRequired && c.BackChannelLogoutURI == "" {
+		invalid("option 'backchannel_logout_session_required' must only be configured with 'backchannel_logout_uri'")
+	}
+
+	for _, option := range []struct {
+		name string
+		uris []string
+	}{
+		{"request_uris", c.RequestURIs},
+		{"jwks_uri", []string{c.JSONWebKeysURI}},
+		{"sector_identifier_uri", []string{c.SectorIdentifierURI}},
+	} {
+		for _, uri := range option.uris {
+			if uri == "" {
+				continue
+			}
+
+			if u, err := url.Parse(uri); err != nil || u.Scheme != "https" || u.Host == "" {
+				invalid("option '%s' has an invalid value '%s': the uri must be an absolute https uri", option.name, uri)
+			}
+		}
+	}
+
+	if c.PreConfiguredConsentDuration != "" && c.ConsentMode != "" && c.ConsentMode != "auto" && c.ConsentMode != "pre-configured" {
+		invalid("option 'pre_configured_consent_duration' must only be configured with consent_mode 'auto' or 'pre-configured'")
+	}
+
+	if c.DPoPBoundAccessTokens && c.TLSClientCertificateBoundTokens {
+		invalid("option 'dpop_bound_access_tokens' must not be configured with 'tls_client_certificate_bound_access_tokens'")
+	}
+
+	if c.JSONWebKeysURI != "" && len(c.JSONWebKeys) != 0 {
+		invalid("option 'jwks_uri' must not be configured with 'jwks'")
+	}
+
+	for i, key := range c.JSONWebKeys {
+		if key.KeyID == "" {
+			invalid("option 'jwks' key %d has no 'key_id'", i+1)
+		}
+
+		if key.Key == "" {
+			invalid("option 'jwks' key %d has no 'key'", i+1)
+		}
+
+		oneOf("jwks.algorithm", key.Algorithm, clientAsymmetricAlgs)
+		oneOf("jwks.use", key.Use, []string{"sig"})
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateClients validates each client and ensures no two clients have the same client ID.
+func ValidateClients(clients []Client) error {
+	var errs []error
+
+	seen := map[string]bool{}
+
+	for _, client := range clients {
+		if err := client.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+
+		if seen[client.ClientID] {
+			errs = append(errs, fmt.Errorf("client '%s': option 'client_id' is not unique", client.ClientID))
+		}
+
+		seen[client.ClientID] = true
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,231 @@
+package oidcc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClientValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(client *Client)
+		expected []string
+	}{
+		{
+			"ShouldPassValidClient",
+			func(client *Client) {},
+			nil,
+		},
+		{
+			"ShouldFailInvalidValues",
+			func(client *Client) {
+				client.GrantTypes = []string{"authorization_code", "password"}
+				client.ResponseModes = []string{"query", "web_message"}
+				client.ConsentMode = "never"
+				client.PKCEChallengeMethod = "S512"
+				client.IDTokenSignedResponseAlg = "none"
+				client.RequestedAudienceMode = "all"
+			},
+			[]string{
+				"option 'grant_types' must be one of 'authorization_code', 'implicit', 'refresh_token', 'client_credentials', 'urn:ietf:params:oauth:grant-type:device_code' but it's configured as 'password'",
+				"option 'response_modes' must be one of 'form_post', 'query', 'fragment', 'jwt', 'form_post.jwt', 'query.jwt', 'fragment.jwt' but it's configured as 'web_message'",
+				"option 'consent_mode' must be one of 'auto', 'explicit', 'implicit', 'pre-configured' but it's configured as 'never'",
+				"option 'requested_audience_mode' must be one of 'explicit', 'implicit' but it's configured as 'all'",
+				"option 'pkce_challenge_method' must be one of 'plain', 'S256' but it's configured as 'S512'",
+				"option 'id_token_signed_response_alg' must be one of 'RS256', 'RS384', 'RS512', 'PS256', 'PS384', 'PS512', 'ES256', 'ES384', 'ES512' but it's configured as 'none'",
+			},
+		},
+		{
+			"ShouldFailPublicClientWithSecret",
+			func(client *Client) {
+				client.Public = true
+			},
+			[]string{
+				"option 'client_secret' must not be configured for a public client",
+				"option 'token_endpoint_auth_method' must be 'none' for a public client but it's configured as 'client_secret_basic'",
+			},
+		},
+		{
+			"ShouldFailConfidentialClientWithoutSecret",
+			func(client *Client) {
+				client.ClientSecret = ""
+			},
+			[]string{"option 'client_secret' is required for a confidential client"},
+		},
+		{
+			"ShouldFailSigningAlgWithoutJWTAuth",
+			func(client *Client) {
+				client.TokenEndpointAuthSigningAlg = "HS256"
+			},
+			[]string{"option 'token_endpoint_auth_signing_alg' must only be configured with token_endpoint_auth_method 'client_secret_jwt' or 'private_key_jwt'"},
+		},
+		{
+			"ShouldFailClientSecretJWTWithAsymmetricAlg",
+			func(client *Client) {
+				client.TokenEndpointAuthMethod, client.TokenEndpointAuthSigningAlg = "client_secret_jwt", "RS256"
+			},
+			[]string{"option 'token_endpoint_auth_signing_alg' must be one of 'HS256', 'HS384', 'HS512' but it's configured as 'RS256'"},
+		},
+		{
+			"ShouldFailPrivateKeyJWTWithoutKeys",
+			func(client *Client) {
+				client.TokenEndpointAuthMethod, client.ClientSecret = "private_key_jwt", ""
+			},
+			[]string{"option 'jwks_uri' or 'jwks' is required with token_endpoint_auth_method 'private_key_jwt'"},
+		},
+		{
+			"ShouldFailBothKeySources",
+			func(client *Client) {
+				client.JSONWebKeysURI = "http://rp.example.com/jwks.json"
+				client.JSONWebKeys = []ClientJWK{{Algorithm: "HS256", Use: "enc"}}
+			},
+			[]string{
+				"option 'jwks_uri' has an invalid value 'http://rp.example.com/jwks.json': the uri must be an absolute https uri",
+				"option 'jwks_uri' must not be configured with 'jwks'",
+				"option 'jwks' key 1 has no 'key_id'",
+				"option 'jwks' key 1 has no 'key'",
+				"option 'jwks.algorithm' must be one of",
+				"option 'jwks.use' must be one of 'sig' but it's configured as 'enc'",
+			},
+		},
+		{
+			"ShouldFailTLSClientAuthWithoutSubjectDN",
+			func(client *Client) {
+				client.TokenEndpointAuthMethod, client.ClientSecret = "tls_client_auth", ""
+			},
+			[]string{"option 'tls_client_auth_subject_dn' is required with token_endpoint_auth_method 'tls_client_auth'"},
+		},
+		{
+			"ShouldFailSelfSignedTLSClientAuthWithoutCertificate",
+			func(client *Client) {
+				client.TokenEndpointAuthMethod, client.ClientSecret = "self_signed_tls_client_auth", ""
+				client.TLSClientAuthSubjectDN = "CN=client"
+				client.JSONWebKeys = []ClientJWK{{KeyID: "a", Key: "key"}}
+			},
+			[]string{
+				"option 'jwks' key 1 has no 'certificate_chain' which is required with token_endpoint_auth_method 'self_signed_tls_client_auth'",
+				"option 'tls_client_auth_subject_dn' must only be configured with token_endpoint_auth_method 'tls_client_auth'",
+			},
+		},
+		{
+			"ShouldFailBothSenderConstrainingMethods",
+			func(client *Client) {
+				client.DPoPBoundAccessTokens, client.TLSClientCertificateBoundTokens = true, true
+			},
+			[]string{"option 'dpop_bound_access_tokens' must not be configured with 'tls_client_certificate_bound_access_tokens'"},
+		},
+		{
+			"ShouldFailLogoutSessionRequiredWithoutURI",
+			func(client *Client) {
+				client.PostLogoutRedirectURIs = []string{"/logout"}
+				client.FrontChannelLogoutSessionRequired, client.BackChannelLogoutSessionRequired = true, true
+			},
+			[]string{
+				"option 'post_logout_redirect_uris' has an invalid value '/logout': the uri must be absolute",
+				"option 'frontchannel_logout_session_required' must only be configured with 'frontchannel_logout_uri'",
+				"option 'backchannel_logout_session_required' must only be configured with 'backchannel_logout_uri'",
+			},
+		},
+		{
+			"ShouldFailMissingGrantTypes",
+			func(client *Client) {
+				client.ResponseTypes = []string{"code id_token"}
+				client.Scopes = []string{"openid", "offline_access"}
+			},
+			[]string{
+				"option 'grant_types' must contain 'implicit' for the response type 'code id_token'",
+				"option 'grant_types' must contain 'refresh_token' for the scope 'offline_access'",
+			},
+		},
+		{
+			"ShouldFailRedirectURIs",
+			func(client *Client) {
+				client.RedirectURIs = nil
+				client.RequestURIs = []string{"/request"}
+			},
+			[]string{
+				"option 'redirect_uris' is required for the authorization_code and implicit grants",
+				"option 'request_uris' has an invalid value '/request': the uri must be an absolute https uri",
+			},
+		},
+		{
+			"ShouldFailConsentDurationWithoutPreConfiguredConsent",
+			func(client *Client) {
+				client.ConsentMode, client.PreConfiguredConsentDuration = "explicit", "1 week"
+			},
+			[]string{"option 'pre_configured_consent_duration' must only be configured with consent_mode 'auto' or 'pre-configured'"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient("conformance-test-1", "$plaintext$secret")
+
+			tc.modify(&client)
+
+			err := client.Validate()
+
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error but got: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			lines := strings.Split(err.Error(), "\n")
+
+			if len(lines) != len(tc.expected) {
+				t.Errorf("expected %d errors but got %d:\n%v", len(tc.expected), len(lines), err)
+			}
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), "client 'conformance-test-1': "+expected) {
+					t.Errorf("expected the error to contain %q but got:\n%v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateClientsDuplicate(t *testing.T) {
+	err := ValidateClients([]Client{newTestClient("conformance-a-1", ""), newTestClient("conformance-a-1", "")})
+
+	if err == nil || err.Error() != "client 'conformance-a-1': option 'client_id' is not unique" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGetClientsValidate(t *testing.T) {
+	root, _ := url.Parse("https://suite.example.com")
+
+	plans, err := NewPlansAll("https://idp.example.com", "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var clients []Client
+
+	for _, plan := range plans {
+		clients = append(clients, plan.GetClients(root)...)
+	}
+
+	if err = ValidateClients(clients); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, client := range clients {
+		if client.IDTokenSignedResponseAlg != "RS256" || client.UserinfoSignedResponseAlg != "none" {
+			t.Errorf("client '%s': unexpected signing algs %s %s", client.ClientID, client.IDTokenSignedResponseAlg, client.UserinfoSignedResponseAlg)
+		}
+
+		if client.Public != (client.RequirePKCE && client.PKCEChallengeMethod == "S256") {
+			t.Errorf("client '%s': expected public clients and only public clients to require S256 PKCE", client.ClientID)
+		}
+	}
+}
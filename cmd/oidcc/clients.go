@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func runClients(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		search string
+		root   string
+		merge  string
+		prefix string
+		hash   bool
+		jwks   string
+		ca     string
+	)
+
+	flags := cfg.flagSet("clients")
+
+	flags.StringVar(&search, "search", "", "include every plan matching this suite search when no plan IDs are provided")
+	flags.StringVar(&root, "root", "", "the suite URL used for the redirect URIs, defaults to the suite API URL without the /api path")
+	flags.StringVar(&merge, "merge", "", "merge the clients into this Authelia configuration file instead of printing them")
+	flags.StringVar(&prefix, "prefix", oidcc.DefaultClientIDPrefix, "the client ID prefix of the generated clients replaced or removed when merging")
+
+	flags.BoolVar(&hash, "hash", false, "write the client secrets as PBKDF2-SHA512 digests instead of plaintext, keeping the digests which still match when merging")
+	flags.StringVar(&ca, "ca", "", "write the certificate authorities of the tls_client_auth clients to this file")
+	flags.StringVar(&jwks, "jwks-uri", "", "register private_key_jwt clients with a jwks_uri below this https base URL served by the jwks command")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		client  *oidcc.APIClient
+		plans   []oidcc.PlanMetadata
+		rootURI *url.URL
+	)
+
+	if root == "" {
+		root = strings.TrimSuffix(strings.TrimSuffix(cfg.url, "/"), "/api")
+	}
+
+	if rootURI, err = url.Parse(root); err != nil {
+		return fmt.Errorf("error parsing root url: %w", err)
+	}
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plans, err = planArgs(client, flags.Args(), search); err != nil {
+		return err
+	}
+
+	if plans, err = planConfigs(ctx, client, plans); err != nil {
+		return err
+	}
+
+	opts := oidcc.ClientOptions{HashSecrets: hash, JWKSURI: jwks}
+
+	if hash && merge != "" {
+		if opts.SecretDigests, err = oidcc.ClientSecretDigestsFile(merge); err != nil {
+			return err
+		}
+	}
+
+	var clients []oidcc.Client
+
+	for _, plan := range plans {
+		var generated []oidcc.Client
+
+		if generated, err = plan.GetClientsWithOptions(rootURI, opts); err != nil {
+			return err
+		}
+
+		clients = append(clients, generated...)
+	}
+
+	if ca != "" {
+		pointers := make([]*oidcc.PlanMetadata, len(plans))
+
+		for i := range plans {
+			pointers[i] = &plans[i]
+		}
+
+		if err = os.WriteFile(ca, []byte(strings.Join(oidcc.ClientCertificateAuthorities(pointers...), "")), 0600); err != nil {
+			return fmt.Errorf("error writing certificate authorities: %w", err)
+		}
+	}
+
+	if merge != "" {
+		if err = oidcc.MergeClientsFile(merge, clients, prefix); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cfg.stderr, "merged %d clients into %s\n", len(clients), merge)
+
+		return nil
+	}
+
+	var data []byte
+
+	if data, err = oidcc.RenderClients(clients); err != nil {
+		return err
+	}
+
+	_, err = cfg.stdout.Write(data)
+
+	return err
+}
+
+// planConfigs returns the plans with the config of any plan listed without it fetched from the suite.
+func planConfigs(ctx context.Context, client *oidcc.APIClient, plans []oidcc.PlanMetadata) (full []oidcc.PlanMetadata, err error) {
+	for _, p := range plans {
+		if p.Config == nil {
+			var plan *oidcc.PlanMetadata
+
+			if plan, err = client.GetPlan(ctx, p.ID); err != nil {
+				return nil, err
+			}
+
+			p = *plan
+		}
+
+		full = append(full, p)
+	}
+
+	return full, nil
+}
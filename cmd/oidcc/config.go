@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+const (
+	envURL         = "OIDCC_URL"
+	envToken       = "OIDCC_TOKEN"
+	envIssuer      = "OIDCC_ISSUER"
+	envSecret      = "OIDCC_SECRET"
+	envTLSCA       = "OIDCC_TLS_CA"
+	envTLSInsecure = "OIDCC_TLS_INSECURE"
+
+	defaultURL = "https://localhost:8443/api"
+)
+
+// config is the configuration shared by every command. Each value defaults to the matching OIDCC_* environment
+// variable and can be overridden by a flag.
+type config struct {
+	name   string
+	usage  string
+	stdout io.Writer
+	stderr io.Writer
+
+	url         string
+	token       string
+	issuer      string
+	secret      string
+	tlsCA       string
+	tlsInsecure bool
+}
+
+func (cfg *config) flagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	flags.SetOutput(cfg.stderr)
+
+	flags.Usage = func() {
+		fmt.Fprintf(cfg.stderr, "Usage: oidcc %s\n\nFlags:\n", cfg.usage)
+
+		flags.PrintDefaults()
+	}
+
+	insecure, _ := strconv.ParseBool(os.Getenv(envTLSInsecure))
+
+	flags.StringVar(&cfg.url, "url", envOr(envURL, defaultURL), "the URL of the suite API ($"+envURL+")")
+	flags.StringVar(&cfg.token, "token", os.Getenv(envToken), "the suite API bearer token ($"+envToken+")")
+	flags.StringVar(&cfg.issuer, "issuer", os.Getenv(envIssuer), "the issuer of the identity provider under test ($"+envIssuer+")")
+	flags.StringVar(&cfg.secret, "secret", os.Getenv(envSecret), "the client secret used for the plans ($"+envSecret+")")
+	flags.StringVar(&cfg.tlsCA, "tls-ca", os.Getenv(envTLSCA), "a PEM file of CA certificates trusted for the suite ($"+envTLSCA+")")
+	flags.BoolVar(&cfg.tlsInsecure, "tls-insecure", insecure, "skip verification of the suite certificate ($"+envTLSInsecure+")")
+
+	return flags
+}
+
+func envOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func (cfg *config) client() (client *oidcc.APIClient, err error) {
+	var root *url.URL
+
+	if root, err = url.Parse(cfg.url); err != nil {
+		return nil, fmt.Errorf("error parsing suite url: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.tlsInsecure,
+	}
+
+	if cfg.tlsCA != "" {
+		var data []byte
+
+		if data, err = os.ReadFile(filepath.Clean(cfg.tlsCA)); err != nil {
+			return nil, fmt.Errorf("error reading tls ca: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("error reading tls ca: no certificates found in '%s'", cfg.tlsCA)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	headers := http.Header{}
+
+	if cfg.token != "" {
+		headers.Set("Authorization", "Bearer "+cfg.token)
+	}
+
+	return oidcc.NewAPIClient(root, headers, tlsConfig), nil
+}
+
+func (cfg *config) requireIssuer() error {
+	if cfg.issuer == "" {
+		return fmt.Errorf("the issuer is required: set the -issuer flag or $%s", envIssuer)
+	}
+
+	return nil
+}
+
+func parsePublish(value string) (publish oidcc.Publish, err error) {
+	switch value {
+	case "", "none":
+		return oidcc.NoPublish, nil
+	case "summary":
+		return oidcc.SummaryPublish, nil
+	case "everything":
+		return oidcc.EverythingPublish, nil
+	default:
+		return oidcc.NoPublish, fmt.Errorf("invalid publish value '%s': must be one of none, summary, or everything", value)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func runJWKS(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		search  string
+		listen  string
+		jwksURI string
+		tlsCert string
+		tlsKey  string
+	)
+
+	flags := cfg.flagSet("jwks")
+
+	flags.StringVar(&search, "search", "", "include every plan matching this suite search when no plan IDs are provided")
+	flags.StringVar(&listen, "listen", "127.0.0.1:9080", "the address to serve /{client_id}/jwks.json on")
+	flags.StringVar(&jwksURI, "jwks-uri", "", "the https base URL the clients are registered with, its host is used for the self-signed certificate")
+	flags.StringVar(&tlsCert, "tls-cert", "", "the PEM certificate file to serve with, a self-signed certificate is generated if empty")
+	flags.StringVar(&tlsKey, "tls-key", "", "the PEM private key file of the -tls-cert certificate")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		client *oidcc.APIClient
+		plans  []oidcc.PlanMetadata
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plans, err = planArgs(client, flags.Args(), search); err != nil {
+		return err
+	}
+
+	if plans, err = planConfigs(ctx, client, plans); err != nil {
+		return err
+	}
+
+	pointers := make([]*oidcc.PlanMetadata, len(plans))
+
+	for i := range plans {
+		pointers[i] = &plans[i]
+	}
+
+	var certificate tls.Certificate
+
+	if certificate, err = jwksCertificate(cfg, listen, jwksURI, tlsCert, tlsKey); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+
+	listener = tls.NewListener(listener, &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{certificate}})
+
+	server := &http.Server{Handler: oidcc.NewJWKSHandler(pointers...), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(cfg.stderr, "serving the keys of %d plans on https://%s\n", len(plans), listener.Addr())
+
+	if err = server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// jwksCertificate loads the certificate the jwks command serves with. The jwks_uri of a client must be https, so if no
+// certificate is configured a self-signed certificate is generated and printed for the identity provider to trust. It
+// is issued for the host of the jwks_uri base URL if set and otherwise for the listen host, which must not be an
+// unspecified address as no client connects to it.
+func jwksCertificate(cfg *config, listen, jwksURI, certFile, keyFile string) (certificate tls.Certificate, err error) {
+	if certFile != "" || keyFile != "" {
+		if certificate, err = tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return certificate, fmt.Errorf("error loading tls certificate: %w", err)
+		}
+
+		return certificate, nil
+	}
+
+	var host string
+
+	if jwksURI != "" {
+		var uri *url.URL
+
+		if uri, err = url.Parse(jwksURI); err != nil {
+			return certificate, fmt.Errorf("error parsing jwks uri: %w", err)
+		}
+
+		if uri.Scheme != "https" || uri.Hostname() == "" {
+			return certificate, fmt.Errorf("error parsing jwks uri: the uri '%s' must be an absolute https uri", jwksURI)
+		}
+
+		host = uri.Hostname()
+	} else {
+		if host, _, err = net.SplitHostPort(listen); err != nil {
+			return certificate, fmt.Errorf("error parsing listen address: %w", err)
+		}
+
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			return certificate, fmt.Errorf("the listen address '%s' has no host to issue the self-signed certificate for: set -jwks-uri to the base URL the clients are registered with or use -tls-cert", listen)
+		}
+	}
+
+	certPEM, keyPEM, err := oidcc.NewSelfSignedServerCertificate(host)
+	if err != nil {
+		return certificate, err
+	}
+
+	if certificate, err = tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err != nil {
+		return certificate, fmt.Errorf("error loading tls certificate: %w", err)
+	}
+
+	fmt.Fprintf(cfg.stderr, "serving with a self-signed certificate which the identity provider must trust:\n%s", certPEM)
+
+	return certificate, nil
+}
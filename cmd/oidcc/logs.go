@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func runLogs(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		follow   bool
+		color    bool
+		http     bool
+		raw      bool
+		interval time.Duration
+	)
+
+	flags := cfg.flagSet("logs")
+
+	flags.BoolVar(&follow, "follow", false, "keep printing new entries until the test instance finishes")
+	flags.BoolVar(&color, "color", isTerminal(cfg.stdout), "colour the result of each entry")
+	flags.BoolVar(&http, "http", false, "include the detail of HTTP exchanges")
+	flags.BoolVar(&raw, "json", false, "print each entry as a line of JSON")
+	flags.DurationVar(&interval, "interval", oidcc.DefaultTailInterval, "the interval between polls when following")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("exactly one test ID is required")
+	}
+
+	var client *oidcc.APIClient
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	formatter := oidcc.LogFormatter{Color: color, HTTP: http}
+	encoder := json.NewEncoder(cfg.stdout)
+
+	write := func(entry oidcc.LogEntry) error {
+		if raw {
+			return encoder.Encode(entry)
+		}
+
+		return formatter.Write(cfg.stdout, entry)
+	}
+
+	if follow {
+		return client.FollowTestLog(ctx, flags.Arg(0), interval, write)
+	}
+
+	var entries []oidcc.LogEntry
+
+	if entries, err = client.GetTestLogAll(ctx, flags.Arg(0)); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err = write(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isTerminal(w any) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,84 @@
+// Command oidcc manages OpenID conformance suite plans, test runs, reports, and the matching identity provider client
+// configuration.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, cfg *config, args []string) error
+}
+
+var commands = []command{
+	{"plans", "plans create|list|delete|sync [flags]", "manage the test plans on the suite", runPlans},
+	{"run", "run [flags] PLAN_ID [MODULE...]", "create and start test instances for the modules of a plan", runRun},
+	{"wait", "wait [flags] TEST_ID...", "wait for test instances to finish", runWait},
+	{"report", "report [flags] [PLAN_ID...]", "print the requirement coverage and results of plans", runReport},
+	{"export", "export [flags] PLAN_ID", "download the export archive of a plan", runExport},
+	{"clients", "clients [flags] [PLAN_ID...]", "print the identity provider client configuration for plans", runClients},
+	{"logs", "logs [flags] TEST_ID", "print or follow the log of a test instance", runLogs},
+	{"jwks", "jwks [flags] [PLAN_ID...]", "serve the public keys of the private_key_jwt clients of plans", runJWKS},
+	{"rp", "rp [flags] PLAN_ID -- COMMAND [ARG...]", "run a relying party command against each module of a relying party plan", runRP},
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+
+	cancel()
+
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+
+		return flag.ErrHelp
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		cfg := &config{name: cmd.name, usage: cmd.usage, stdout: stdout, stderr: stderr}
+
+		return cmd.run(ctx, cfg, args[1:])
+	}
+
+	usage(stderr)
+
+	return fmt.Errorf("unknown command '%s'", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: oidcc COMMAND [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Every command accepts the suite and TLS flags which default to the OIDCC_* environment variables.")
+	fmt.Fprintln(w, "Run 'oidcc COMMAND -h' for the flags of a command.")
+}
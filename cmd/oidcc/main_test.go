@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func TestRunUnknownCommand(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	if err := run(context.Background(), []string{"nope"}, stdout, stderr); err == nil || !strings.Contains(err.Error(), "unknown command 'nope'") {
+		t.Errorf("expected an unknown command error but got %v", err)
+	}
+
+	if !strings.Contains(stderr.String(), "Commands:") {
+		t.Errorf("expected the usage to be printed but got %s", stderr.String())
+	}
+}
+
+func TestRunPlansListUsesEnvironment(t *testing.T) {
+	var authorization string
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+
+		if r.URL.Path != "/suite/api/plan" || r.URL.Query().Get("search") != "Comprehensive" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(oidcc.PlanMetadataResponse{
+			RecordsFiltered: 1,
+			Data:            []oidcc.PlanMetadata{{ID: "abc", Name: "oidcc-test-plan", Config: &oidcc.PlanConfig{Alias: "conformance-basic-code"}}},
+		})
+	}))
+
+	defer server.Close()
+
+	t.Setenv(envURL, server.URL+"/suite/api")
+	t.Setenv(envToken, "token")
+	t.Setenv(envTLSInsecure, "true")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	if err := run(context.Background(), []string{"plans", "list", "-search", "Comprehensive"}, stdout, stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	if authorization != "Bearer token" {
+		t.Errorf("expected the token to be sent but got '%s'", authorization)
+	}
+
+	if !strings.Contains(stdout.String(), "abc") || !strings.Contains(stdout.String(), "conformance-basic-code") {
+		t.Errorf("unexpected output:\n%s", stdout.String())
+	}
+}
+
+func TestRunPlansCreateRequiresIssuer(t *testing.T) {
+	t.Setenv(envIssuer, "")
+
+	err := run(context.Background(), []string{"plans", "create", "-url", "https://127.0.0.1:1/api"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "issuer is required") {
+		t.Errorf("expected an issuer error but got %v", err)
+	}
+}
+
+func TestRunClientsJWKSURI(t *testing.T) {
+	plan, err := oidcc.NewComprehensiveDiscoveryPlan("jwks", "JWKS", "secret", "", "https://idp.example.com", "private_key_jwt", "code", "default", oidcc.NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan.ID = "abc"
+
+	for _, client := range []*oidcc.PlanClient{plan.Config.Client, plan.Config.Client2} {
+		if err = client.GenerateKey("RS256"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/plan/abc" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(plan)
+	}))
+
+	defer server.Close()
+
+	t.Setenv(envURL, server.URL+"/api")
+	t.Setenv(envToken, "token")
+	t.Setenv(envTLSInsecure, "true")
+
+	stdout := &bytes.Buffer{}
+
+	if err = run(context.Background(), []string{"clients", "-jwks-uri", "https://127.0.0.1:9080", "abc"}, stdout, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(stdout.String(), "https://127.0.0.1:9080/conformance-jwks-1/jwks.json") {
+		t.Errorf("expected the clients to be registered with the jwks_uri but got:\n%s", stdout.String())
+	}
+
+	err = run(context.Background(), []string{"clients", "-jwks-uri", "http://127.0.0.1:9080", "abc"}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "must be an absolute https uri") {
+		t.Errorf("expected an http jwks_uri to fail validation but got %v", err)
+	}
+}
+
+func TestJWKSCertificate(t *testing.T) {
+	cfg := &config{stderr: &bytes.Buffer{}}
+
+	for _, listen := range []string{"0.0.0.0:9080", "[::]:9080", ":9080"} {
+		if _, err := jwksCertificate(cfg, listen, "", "", ""); err == nil || !strings.Contains(err.Error(), "no host to issue the self-signed certificate for") {
+			t.Errorf("%s: expected an error for the unspecified address but got %v", listen, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		listen, jwksURI, host string
+	}{
+		{"127.0.0.1:9080", "", "127.0.0.1"},
+		{"0.0.0.0:9080", "https://jwks.example.com:9080", "jwks.example.com"},
+	} {
+		certificate, err := jwksCertificate(cfg, tc.listen, tc.jwksURI, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = leaf.VerifyHostname(tc.host); err != nil {
+			t.Errorf("%s: %v", tc.listen, err)
+		}
+	}
+
+	if _, err := jwksCertificate(cfg, "0.0.0.0:9080", "http://jwks.example.com", "", ""); err == nil {
+		t.Errorf("expected an error for an http jwks uri")
+	}
+}
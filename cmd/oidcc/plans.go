@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+// planFlags are the flags which select the plans built by the create and sync commands.
+type planFlags struct {
+	profile    string
+	publish    string
+	strength   int
+	seed       int64
+	derive     bool
+	dynamic    bool
+	iat        string
+	static     bool
+	document   string
+	skip       bool
+	advertised bool
+
+	rpClientID       string
+	rpRedirectURI    string
+	rpClientAuthType string
+
+	// doc is the discovery document loaded by plans for -static or -advertised, which preflight checks instead of
+	// fetching the discovery document of the issuer.
+	doc *oidcc.DiscoveryDocument
+}
+
+func (f *planFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.profile, "profile", "certification", "the plans to build: certification, comprehensive, logout, fapi1, fapi2-sp, fapi2-ms, all, or rp which isn't included in all")
+	flags.StringVar(&f.publish, "publish", "summary", "the publish setting of the plans: none, summary, or everything")
+	flags.IntVar(&f.strength, "strength", 0, "sample the comprehensive matrix with n-wise coverage of this strength, 0 builds the full matrix")
+	flags.Int64Var(&f.seed, "seed", 1, "the seed used to sample the comprehensive matrix")
+	flags.BoolVar(&f.dynamic, "dynamic", false, "also build the dynamic client registration certification plan and comprehensive variants")
+	flags.StringVar(&f.iat, "initial-access-token", "", "the initial access token the suite sends to the registration endpoint of dynamic plans")
+	flags.BoolVar(&f.static, "static", false, "use static server metadata from the discovery document instead of discovery")
+	flags.StringVar(&f.document, "discovery-document", "", "the discovery document file used with -static and -advertised, fetched from the issuer if empty")
+	flags.BoolVar(&f.advertised, "advertised", false, "skip the comprehensive variants using a client auth type, response type, or response mode the discovery document does not advertise")
+	flags.BoolVar(&f.derive, "derive-secrets", false, "use the secret as a master secret and derive a distinct secret for every client")
+	flags.StringVar(&f.rpClientID, "rp-client-id", "conformance-rp", "the client id of the relying party tested by the rp plans")
+	flags.StringVar(&f.rpRedirectURI, "rp-redirect-uri", "", "the redirect uri of the relying party tested by the rp plans, required by the rp profile")
+	flags.StringVar(&f.rpClientAuthType, "rp-client-auth-type", "client_secret_basic", "the token endpoint auth method of the relying party tested by the rp plans")
+	flags.BoolVar(&f.skip, "skip-preflight", false, "skip checking the discovery document and jwks of the issuer support the plans")
+}
+
+var fapiProfiles = []struct {
+	name   string
+	matrix func(issuer string, publish oidcc.Publish) *oidcc.VariantMatrix
+}{
+	{"fapi1", oidcc.NewFAPI1AdvancedVariantMatrix},
+	{"fapi2-sp", oidcc.NewFAPI2SecurityProfileVariantMatrix},
+	{"fapi2-ms", oidcc.NewFAPI2MessageSigningVariantMatrix},
+}
+
+func (f *planFlags) plans(ctx context.Context, cfg *config) (plans []*oidcc.PlanMetadata, err error) {
+	if f.profile != "rp" {
+		if err = cfg.requireIssuer(); err != nil {
+			return nil, err
+		}
+	}
+
+	var publish oidcc.Publish
+
+	if publish, err = parsePublish(f.publish); err != nil {
+		return nil, err
+	}
+
+	if f.static || f.advertised {
+		if f.doc, err = f.discoveryDocument(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.profile == "certification" || f.profile == "all" {
+		var certification []*oidcc.PlanMetadata
+
+		if certification, err = oidcc.NewPlansAll(cfg.issuer, cfg.secret, publish); err != nil {
+			return nil, err
+		}
+
+		plans = append(plans, certification...)
+
+		if f.dynamic {
+			var plan *oidcc.PlanMetadata
+
+			if plan, err = oidcc.NewCertificationProfileDynamicDiscoveryPlan("certification-profile-dynamic", "Certification Profile: Dynamic", cfg.issuer, f.registration(), publish); err != nil {
+				return nil, err
+			}
+
+			plans = append(plans, plan)
+		}
+	}
+
+	if f.profile == "comprehensive" || f.profile == "all" {
+		var comprehensive []*oidcc.PlanMetadata
+
+		opts := oidcc.ComprehensiveMatrixOptions{DynamicClients: f.dynamic, Registration: f.registration()}
+
+		if f.advertised {
+			opts.Discovery = f.doc
+		}
+
+		matrix := oidcc.NewComprehensiveVariantMatrixWithOptions(cfg.secret, cfg.issuer, publish, opts)
+
+		if comprehensive, err = matrix.SamplePlans(f.strength, f.seed); err != nil {
+			return nil, err
+		}
+
+		if f.advertised {
+			if err = printSkipped(cfg.stderr, matrix.Skipped()); err != nil {
+				return nil, err
+			}
+		}
+
+		plans = append(plans, comprehensive...)
+	}
+
+	if f.profile == "logout" || f.profile == "all" {
+		var logout []*oidcc.PlanMetadata
+
+		if logout, err = oidcc.NewLogoutPlansAll(cfg.issuer, cfg.secret, publish); err != nil {
+			return nil, err
+		}
+
+		plans = append(plans, logout...)
+	}
+
+	for _, fapi := range fapiProfiles {
+		if f.profile != fapi.name && f.profile != "all" {
+			continue
+		}
+
+		var sampled []*oidcc.PlanMetadata
+
+		if sampled, err = fapi.matrix(cfg.issuer, publish).SamplePlans(f.strength, f.seed); err != nil {
+			return nil, err
+		}
+
+		plans = append(plans, sampled...)
+	}
+
+	if f.profile == "rp" {
+		client := &oidcc.PlanClient{ClientID: f.rpClientID, ClientSecret: cfg.secret, RedirectURI: f.rpRedirectURI}
+
+		if plans, err = oidcc.NewClientPlansAll(f.rpClientAuthType, client, publish); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("invalid profile '%s': must be one of certification, comprehensive, logout, fapi1, fapi2-sp, fapi2-ms, all, or rp", f.profile)
+	}
+
+	if f.static {
+		oidcc.UseStaticServerMetadata(f.doc, plans...)
+	}
+
+	if f.derive {
+		if cfg.secret == "" {
+			return nil, fmt.Errorf("the secret is required to derive client secrets: set the -secret flag or $%s", envSecret)
+		}
+
+		oidcc.DeriveClientSecrets(cfg.secret, plans...)
+	}
+
+	return plans, nil
+}
+
+// printSkipped writes the variants skipped by a matrix so the combinations the provider does not advertise are visible.
+func printSkipped(w io.Writer, skipped []oidcc.VariantSkip) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SKIPPED VARIANT\tREASON")
+
+	for _, skip := range skipped {
+		fmt.Fprintf(tw, "%s\t%s\n", skip.Combination, skip.Reason)
+	}
+
+	return tw.Flush()
+}
+
+func (f *planFlags) discoveryDocument(ctx context.Context, cfg *config) (*oidcc.DiscoveryDocument, error) {
+	if f.document == "" {
+		return oidcc.FetchDiscoveryDocument(ctx, nil, cfg.issuer)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(f.document))
+	if err != nil {
+		return nil, fmt.Errorf("error reading discovery document: %w", err)
+	}
+
+	return oidcc.ParseDiscoveryDocument(data)
+}
+
+// preflight checks the issuer supports the plans, printing any findings and returning an error if any are fatal.
+func (f *planFlags) preflight(ctx context.Context, cfg *config, plans []*oidcc.PlanMetadata) error {
+	if f.skip || f.profile == "rp" {
+		return nil
+	}
+
+	var report *oidcc.PreflightReport
+
+	if f.doc != nil {
+		report = oidcc.PreflightDocument(ctx, nil, cfg.issuer, f.doc, plans...)
+	} else {
+		report = oidcc.Preflight(ctx, nil, cfg.issuer, plans...)
+	}
+
+	if len(report.Findings) != 0 {
+		if err := report.WriteTable(cfg.stderr); err != nil {
+			return err
+		}
+	}
+
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("the issuer failed the preflight checks, use -skip-preflight to create the plans anyway: %w", err)
+	}
+
+	return nil
+}
+
+func (f *planFlags) registration() *oidcc.PlanRegistration {
+	if f.iat == "" {
+		return nil
+	}
+
+	return &oidcc.PlanRegistration{InitialAccessToken: f.iat}
+}
+
+func runPlans(ctx context.Context, cfg *config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a plans command is required: create, list, delete, or sync")
+	}
+
+	switch args[0] {
+	case "create":
+		return runPlansCreate(ctx, cfg, args[1:])
+	case "list":
+		return runPlansList(ctx, cfg, args[1:])
+	case "delete":
+		return runPlansDelete(ctx, cfg, args[1:])
+	case "sync":
+		return runPlansSync(ctx, cfg, args[1:])
+	default:
+		return fmt.Errorf("unknown plans command '%s': must be one of create, list, delete, or sync", args[0])
+	}
+}
+
+func runPlansCreate(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		pf          planFlags
+		atomic      bool
+		concurrency int
+	)
+
+	flags := cfg.flagSet("plans create")
+
+	pf.register(flags)
+
+	flags.BoolVar(&atomic, "atomic", false, "delete every created plan if any plan fails to be created")
+	flags.IntVar(&concurrency, "concurrency", 1, "the maximum number of plans created at the same time")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		client    *oidcc.APIClient
+		plans     []*oidcc.PlanMetadata
+		responses []*oidcc.PlanCreateResponse
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plans, err = pf.plans(ctx, cfg); err != nil {
+		return err
+	}
+
+	if err = pf.preflight(ctx, cfg, plans); err != nil {
+		return err
+	}
+
+	responses, err = client.PostPlansWithOptions(oidcc.PostPlansOptions{Atomic: atomic, Concurrency: concurrency}, plans...)
+
+	tw := tabwriter.NewWriter(cfg.stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tNAME\tMODULES")
+
+	for _, response := range responses {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", response.ID, response.Name, len(response.Modules))
+	}
+
+	if flushErr := tw.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return err
+}
+
+func runPlansList(_ context.Context, cfg *config, args []string) (err error) {
+	var (
+		search string
+		public bool
+	)
+
+	flags := cfg.flagSet("plans list")
+
+	flags.StringVar(&search, "search", "", "only list the plans matching this suite search")
+	flags.BoolVar(&public, "public", false, "list the published plans of every user")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		client *oidcc.APIClient
+		plans  []oidcc.PlanMetadata
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plans, err = client.GetPlansAll(public, search); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(cfg.stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tNAME\tALIAS\tDESCRIPTION\tSTARTED")
+
+	for _, plan := range plans {
+		alias, description := "", plan.Description
+
+		if plan.Config != nil {
+			alias, description = plan.Config.Alias, plan.Config.Description
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", plan.ID, plan.Name, alias, description, plan.Started.Format("2006-01-02 15:04"))
+	}
+
+	return tw.Flush()
+}
+
+func runPlansDelete(_ context.Context, cfg *config, args []string) (err error) {
+	var (
+		search string
+		all    bool
+	)
+
+	flags := cfg.flagSet("plans delete")
+
+	flags.StringVar(&search, "search", "", "delete every plan matching this suite search")
+	flags.BoolVar(&all, "all", false, "delete every plan, required when neither plan IDs nor a search are provided")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	var client *oidcc.APIClient
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	var plans []oidcc.PlanMetadata
+
+	switch {
+	case flags.NArg() != 0:
+		for _, id := range flags.Args() {
+			plans = append(plans, oidcc.PlanMetadata{ID: id})
+		}
+	case search != "" || all:
+		if plans, err = client.GetPlansAll(false, search); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("plan IDs, the -search flag, or the -all flag are required")
+	}
+
+	for _, plan := range plans {
+		var ok bool
+
+		if ok, err = client.DeletePlan(plan); err != nil {
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("suite refused to delete plan '%s'", plan.ID)
+		}
+
+		fmt.Fprintf(cfg.stdout, "deleted %s\n", plan.ID)
+	}
+
+	return nil
+}
+
+func runPlansSync(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		pf      planFlags
+		opts    oidcc.SyncOptions
+		archive string
+	)
+
+	flags := cfg.flagSet("plans sync")
+
+	pf.register(flags)
+
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "print the changes without making them")
+	flags.BoolVar(&opts.DeleteOrphans, "delete-orphans", false, "delete existing plans which are not desired instead of reporting them")
+	flags.StringVar(&opts.Search, "search", "", "only consider the existing plans matching this suite search")
+	flags.StringVar(&archive, "archive", "", "append each deleted plan to this file as a line of JSON")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		client *oidcc.APIClient
+		plans  []*oidcc.PlanMetadata
+		result *oidcc.SyncResult
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plans, err = pf.plans(ctx, cfg); err != nil {
+		return err
+	}
+
+	if err = pf.preflight(ctx, cfg, plans); err != nil {
+		return err
+	}
+
+	if archive != "" && !opts.DryRun {
+		var f *os.File
+
+		if f, err = os.OpenFile(filepath.Clean(archive), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err != nil {
+			return fmt.Errorf("error opening archive file: %w", err)
+		}
+
+		defer f.Close()
+
+		opts.Archive = oidcc.ArchivePlansJSON(f)
+	}
+
+	result, err = client.SyncPlans(plans, opts)
+
+	if result != nil {
+		if printErr := result.Print(cfg.stdout); err == nil {
+			err = printErr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func runReport(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		search string
+		format string
+	)
+
+	flags := cfg.flagSet("report")
+
+	flags.StringVar(&search, "search", "", "report on every plan matching this suite search when no plan IDs are provided")
+	flags.StringVar(&format, "format", "table", "the output format: table or json")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid format '%s': must be one of table or json", format)
+	}
+
+	var (
+		client *oidcc.APIClient
+		plans  []oidcc.PlanMetadata
+		report *oidcc.CoverageReport
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plans, err = planArgs(client, flags.Args(), search); err != nil {
+		return err
+	}
+
+	if report, err = client.RequirementCoverage(ctx, plans...); err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return report.WriteJSON(cfg.stdout)
+	}
+
+	return report.WriteTable(cfg.stdout)
+}
+
+// planArgs returns the plans with the IDs, or every plan matching the search if there are no IDs.
+func planArgs(client *oidcc.APIClient, ids []string, search string) (plans []oidcc.PlanMetadata, err error) {
+	if len(ids) == 0 {
+		return client.GetPlansAll(false, search)
+	}
+
+	for _, id := range ids {
+		plans = append(plans, oidcc.PlanMetadata{ID: id})
+	}
+
+	return plans, nil
+}
+
+func runExport(ctx context.Context, cfg *config, args []string) (err error) {
+	var output string
+
+	flags := cfg.flagSet("export")
+
+	flags.StringVar(&output, "output", "", "the file the archive is written to, - writes to stdout, defaults to PLAN_ID.zip")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("exactly one plan ID is required")
+	}
+
+	id := flags.Arg(0)
+
+	if output == "" {
+		output = id + ".zip"
+	}
+
+	var (
+		client  *oidcc.APIClient
+		archive io.ReadCloser
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if archive, err = client.ExportPlan(ctx, id); err != nil {
+		return err
+	}
+
+	defer archive.Close()
+
+	if output == "-" {
+		_, err = io.Copy(cfg.stdout, archive)
+
+		return err
+	}
+
+	var f *os.File
+
+	if f, err = os.Create(filepath.Clean(output)); err != nil {
+		return fmt.Errorf("error creating export file: %w", err)
+	}
+
+	if _, err = io.Copy(f, archive); err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("error writing export file: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("error writing export file: %w", err)
+	}
+
+	fmt.Fprintf(cfg.stderr, "exported plan %s to %s\n", id, output)
+
+	return nil
+}
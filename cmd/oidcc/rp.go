@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func runRP(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		modules  string
+		interval time.Duration
+		timeout  time.Duration
+	)
+
+	flags := cfg.flagSet("rp")
+
+	flags.StringVar(&modules, "modules", "", "a comma separated list of the modules to run, every module of the plan is run if empty")
+	flags.DurationVar(&interval, "interval", oidcc.DefaultTailInterval, "the interval between polls while waiting")
+	flags.DurationVar(&timeout, "finish-timeout", time.Minute, "the maximum time to wait for a test instance to finish after the relying party exits, 0 waits forever")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("a plan ID is required")
+	}
+
+	command := flags.Args()[1:]
+
+	if len(command) != 0 && command[0] == "--" {
+		command = command[1:]
+	}
+
+	if len(command) == 0 {
+		return fmt.Errorf("the relying party command is required after the plan ID")
+	}
+
+	var client *oidcc.APIClient
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	opts := oidcc.RPRunOptions{Interval: interval, FinishTimeout: timeout}
+
+	if modules != "" {
+		opts.Modules = strings.Split(modules, ",")
+	}
+
+	driver := &oidcc.CommandRPDriver{Path: command[0], Args: command[1:], Stdout: cfg.stderr, Stderr: cfg.stderr}
+
+	results, err := client.RunRPPlan(ctx, flags.Arg(0), driver, opts)
+
+	var failed int
+
+	for _, result := range results {
+		fmt.Fprintf(cfg.stdout, "%s\t%s\t%s\t%s\n", result.TestID, result.Module, result.Info.Status, result.Info.Result)
+
+		if result.Err != nil {
+			fmt.Fprintf(cfg.stderr, "%s\t%s\t%v\n", result.TestID, result.Module, result.Err)
+		}
+
+		if result.Info.Result == oidcc.TestResultFailed {
+			failed++
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d test instance(s) failed", failed)
+	}
+
+	return nil
+}
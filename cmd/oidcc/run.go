@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/james-d-elliott/go-oidcc"
+)
+
+func runRun(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		wait         bool
+		placeholders bool
+		interval     time.Duration
+	)
+
+	flags := cfg.flagSet("run")
+
+	flags.BoolVar(&wait, "wait", false, "wait for each test instance to finish before starting the next")
+	flags.BoolVar(&placeholders, "placeholder-images", false, "upload generated placeholder images to review modules once they finish, requires -wait")
+	flags.DurationVar(&interval, "interval", oidcc.DefaultTailInterval, "the interval between polls while waiting")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("a plan ID is required")
+	}
+
+	var (
+		client *oidcc.APIClient
+		plan   *oidcc.PlanMetadata
+	)
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	if plan, err = client.GetPlan(ctx, flags.Arg(0)); err != nil {
+		return err
+	}
+
+	modules := flags.Args()[1:]
+
+	var failed int
+
+	for _, module := range plan.Modules {
+		if len(modules) != 0 && !slices.Contains(modules, module.TestModule) {
+			continue
+		}
+
+		var response *oidcc.TestCreateResponse
+
+		if response, err = client.CreateTestInstance(ctx, plan.ID, module.TestModule, module.Variant); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cfg.stdout, "%s\t%s\t%s\n", response.ID, module.TestModule, response.URL)
+
+		if !wait {
+			continue
+		}
+
+		var info *oidcc.TestInfo
+
+		if info, err = client.WaitForTest(ctx, response.ID, interval); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cfg.stdout, "%s\t%s\t%s\t%s\n", response.ID, module.TestModule, info.Status, info.Result)
+
+		if info.Result == oidcc.TestResultFailed {
+			failed++
+		}
+
+		if placeholders && info.Result == oidcc.TestResultReview {
+			var uploaded []string
+
+			if uploaded, err = client.UploadReviewImages(ctx, response.ID, oidcc.ImageUploadOptions{Placeholder: true}); err != nil {
+				return err
+			}
+
+			for _, placeholder := range uploaded {
+				fmt.Fprintf(cfg.stdout, "%s\t%s\tuploaded placeholder image %s\n", response.ID, module.TestModule, placeholder)
+			}
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d test instance(s) failed", failed)
+	}
+
+	return nil
+}
+
+func runWait(ctx context.Context, cfg *config, args []string) (err error) {
+	var (
+		interval time.Duration
+		timeout  time.Duration
+	)
+
+	flags := cfg.flagSet("wait")
+
+	flags.DurationVar(&interval, "interval", oidcc.DefaultTailInterval, "the interval between polls")
+	flags.DurationVar(&timeout, "timeout", 0, "the maximum time to wait, 0 waits forever")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("at least one test ID is required")
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+
+		defer cancel()
+	}
+
+	var client *oidcc.APIClient
+
+	if client, err = cfg.client(); err != nil {
+		return err
+	}
+
+	var failed int
+
+	for _, id := range flags.Args() {
+		var info *oidcc.TestInfo
+
+		if info, err = client.WaitForTest(ctx, id, interval); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cfg.stdout, "%s\t%s\t%s\t%s\n", id, info.TestName, info.Status, info.Result)
+
+		if info.Result == oidcc.TestResultFailed {
+			failed++
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d test instance(s) failed", failed)
+	}
+
+	return nil
+}
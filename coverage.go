@@ -0,0 +1,272 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// ParseRequirement splits a suite requirement reference such as OIDCC-3.1.2.1 or RFC6749-4.1.2 into the spec and the
+// section. The section starts after the first hyphen which is followed by a digit.
+func ParseRequirement(requirement string) (spec, section string) {
+	for i := 0; i < len(requirement)-1; i++ {
+		if requirement[i] == '-' && requirement[i+1] >= '0' && requirement[i+1] <= '9' {
+			return requirement[:i], requirement[i+1:]
+		}
+	}
+
+	return requirement, ""
+}
+
+type CoverageReport struct {
+	Specs   []CoverageSpec   `json:"specs"`
+	Modules []CoverageModule `json:"modules"`
+}
+
+type CoverageSpec struct {
+	Spec     string            `json:"spec"`
+	Result   LogResult         `json:"result"`
+	Sections []CoverageSection `json:"sections"`
+}
+
+type CoverageSection struct {
+	Section     string    `json:"section"`
+	Requirement string    `json:"requirement"`
+	Result      LogResult `json:"result"`
+	Modules     []string  `json:"modules"`
+}
+
+type CoverageModule struct {
+	PlanID   string     `json:"plan_id,omitempty"`
+	PlanName string     `json:"plan_name,omitempty"`
+	Alias    string     `json:"alias,omitempty"`
+	Module   string     `json:"module"`
+	TestID   string     `json:"test_id,omitempty"`
+	Result   TestResult `json:"result,omitempty"`
+
+	ProbableCause *TriageFinding `json:"probable_cause,omitempty"`
+}
+
+// CoverageBuilder accumulates the requirements exercised by each module and the worst result logged against them. The
+// Triager, if set, assigns a probable cause to each failing or warning module.
+type CoverageBuilder struct {
+	Triager *Triager
+
+	modules      []CoverageModule
+	requirements map[string]*CoverageSection
+}
+
+func NewCoverageBuilder() *CoverageBuilder {
+	return &CoverageBuilder{Triager: NewTriager(), requirements: map[string]*CoverageSection{}}
+}
+
+// Add records the requirements referenced by the log entries of the module. If the module has no result the worst
+// result in the log entries is used.
+func (b *CoverageBuilder) Add(module CoverageModule, entries []LogEntry) {
+	var worst LogResult
+
+	for _, entry := range entries {
+		worst = worst.Worse(entry.Result)
+
+		for _, requirement := range entry.Requirements {
+			section, ok := b.requirements[requirement]
+			if !ok {
+				_, s := ParseRequirement(requirement)
+
+				section = &CoverageSection{Section: s, Requirement: requirement}
+
+				b.requirements[requirement] = section
+			}
+
+			section.Result = section.Result.Worse(entry.Result)
+
+			if n := len(section.Modules); n == 0 || section.Modules[n-1] != module.Module {
+				section.Modules = append(section.Modules, module.Module)
+			}
+		}
+	}
+
+	if module.Result == "" && len(entries) != 0 {
+		module.Result = TestResultFromLog(worst)
+	}
+
+	if b.Triager != nil && (module.Result == TestResultFailed || module.Result == TestResultWarning) {
+		module.ProbableCause = b.Triager.ProbableCause(entries)
+	}
+
+	b.modules = append(b.modules, module)
+}
+
+func (b *CoverageBuilder) Report() *CoverageReport {
+	specs := map[string]*CoverageSpec{}
+
+	for _, section := range b.requirements {
+		name, _ := ParseRequirement(section.Requirement)
+
+		spec, ok := specs[name]
+		if !ok {
+			spec = &CoverageSpec{Spec: name}
+
+			specs[name] = spec
+		}
+
+		modules := append([]string(nil), section.Modules...)
+
+		sort.Strings(modules)
+
+		s := *section
+
+		s.Modules = compactStrings(modules)
+
+		spec.Result = spec.Result.Worse(section.Result)
+		spec.Sections = append(spec.Sections, s)
+	}
+
+	report := &CoverageReport{Modules: append([]CoverageModule(nil), b.modules...)}
+
+	for _, spec := range specs {
+		sort.Slice(spec.Sections, func(i, j int) bool {
+			return compareSections(spec.Sections[i].Section, spec.Sections[j].Section) < 0
+		})
+
+		report.Specs = append(report.Specs, *spec)
+	}
+
+	sort.Slice(report.Specs, func(i, j int) bool {
+		return report.Specs[i].Spec < report.Specs[j].Spec
+	})
+
+	return report
+}
+
+func compactStrings(values []string) (compacted []string) {
+	for i, value := range values {
+		if i == 0 || values[i-1] != value {
+			compacted = append(compacted, value)
+		}
+	}
+
+	return compacted
+}
+
+// compareSections orders sections such as 3.1.2.10 after 3.1.2.9 by comparing each numeric part as a number.
+func compareSections(a, b string) int {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool {
+			return r == '.' || r == '-'
+		})
+	}
+
+	pa, pb := split(a), split(b)
+
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				return na - nb
+			}
+		case pa[i] != pb[i]:
+			return strings.Compare(pa[i], pb[i])
+		}
+	}
+
+	return len(pa) - len(pb)
+}
+
+func (r *CoverageReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SPEC\tSECTION\tRESULT\tMODULES")
+
+	for _, spec := range r.Specs {
+		for _, section := range spec.Sections {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", spec.Spec, section.Section, section.Result, len(section.Modules))
+		}
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "PLAN\tMODULE\tTEST ID\tRESULT\tPROBABLE CAUSE")
+
+	for _, module := range r.Modules {
+		cause := ""
+
+		if module.ProbableCause != nil {
+			cause = module.ProbableCause.String()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", module.Alias, module.Module, module.TestID, module.Result, cause)
+	}
+
+	return tw.Flush()
+}
+
+func (r *CoverageReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+// RequirementCoverage builds a CoverageReport from the latest test instance of every module in each of the plans.
+// Modules which have not been run are included in the report without a result.
+func (c *APIClient) RequirementCoverage(ctx context.Context, plans ...PlanMetadata) (report *CoverageReport, err error) {
+	builder := NewCoverageBuilder()
+
+	for _, p := range plans {
+		var plan *PlanMetadata
+
+		if plan, err = c.GetPlan(ctx, p.ID); err != nil {
+			return nil, fmt.Errorf("error retrieving plan '%s': %w", p.ID, err)
+		}
+
+		alias := ""
+
+		if plan.Config != nil {
+			alias = plan.Config.Alias
+		}
+
+		for _, m := range plan.Modules {
+			module := CoverageModule{
+				PlanID:   plan.ID,
+				PlanName: plan.Name,
+				Alias:    alias,
+				Module:   m.TestModule,
+				TestID:   m.LatestInstanceID(),
+			}
+
+			if module.TestID == "" {
+				builder.Add(module, nil)
+
+				continue
+			}
+
+			var (
+				info    *TestInfo
+				entries []LogEntry
+			)
+
+			if info, err = c.GetTestInfo(ctx, module.TestID); err != nil {
+				return nil, err
+			}
+
+			module.Result = info.Result
+
+			if entries, err = c.GetTestLogAll(ctx, module.TestID); err != nil {
+				return nil, err
+			}
+
+			builder.Add(module, entries)
+		}
+	}
+
+	return builder.Report(), nil
+}
@@ -0,0 +1,115 @@
+package oidcc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseRequirement(t *testing.T) {
+	testCases := []struct {
+		requirement, spec, section string
+	}{
+		{"OIDCC-3.1.2.1", "OIDCC", "3.1.2.1"},
+		{"RFC6749-4.1.2", "RFC6749", "4.1.2"},
+		{"FAPI1-ADV-5.2.2-1", "FAPI1-ADV", "5.2.2-1"},
+		{"BCP195", "BCP195", ""},
+	}
+
+	for _, tc := range testCases {
+		if spec, section := ParseRequirement(tc.requirement); spec != tc.spec || section != tc.section {
+			t.Errorf("%s: expected '%s' '%s' but got '%s' '%s'", tc.requirement, tc.spec, tc.section, spec, section)
+		}
+	}
+}
+
+func TestRequirementCoverage(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	plan := suite.add(PlanMetadata{
+		Name:   "oidcc-basic-certification-test-plan",
+		Config: &PlanConfig{Alias: "basic"},
+		Modules: []PlanModule{
+			{TestModule: "oidcc-server", Instances: []any{"old", "test-1"}},
+			{TestModule: "oidcc-ensure-redirect-uri-in-authorization-request", Instances: []any{"test-2"}},
+			{TestModule: "oidcc-refresh-token"},
+		},
+	})
+
+	suite.setResult("test-1", TestResultPassed)
+	suite.setResult("test-2", TestResultFailed)
+
+	suite.log("test-1",
+		LogEntry{Time: 1, Result: LogResultSuccess, Requirements: []string{"OIDCC-3.1.2.10", "RFC6749-4.1.2"}},
+		LogEntry{Time: 2, Result: LogResultWarning, Requirements: []string{"OIDCC-3.1.2.9"}},
+	)
+	suite.log("test-2",
+		LogEntry{Time: 1, Result: LogResultSuccess, Requirements: []string{"OIDCC-3.1.2.9"}},
+		LogEntry{Time: 2, Result: LogResultFailure, Requirements: []string{"OIDCC-3.1.2.1"}, Message: "redirect_uri mismatch"},
+	)
+
+	report, err := suite.client().RequirementCoverage(context.Background(), *plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Specs) != 2 || report.Specs[0].Spec != "OIDCC" || report.Specs[1].Spec != "RFC6749" {
+		t.Fatalf("unexpected specs %+v", report.Specs)
+	}
+
+	oidcc := report.Specs[0]
+
+	if oidcc.Result != LogResultFailure {
+		t.Errorf("expected the spec result to be the worst section result but got %s", oidcc.Result)
+	}
+
+	var sections []string
+
+	for _, section := range oidcc.Sections {
+		sections = append(sections, section.Section+"="+string(section.Result)+"/"+strings.Join(section.Modules, "+"))
+	}
+
+	expected := "3.1.2.1=FAILURE/oidcc-ensure-redirect-uri-in-authorization-request," +
+		"3.1.2.9=WARNING/oidcc-ensure-redirect-uri-in-authorization-request+oidcc-server," +
+		"3.1.2.10=SUCCESS/oidcc-server"
+
+	if strings.Join(sections, ",") != expected {
+		t.Errorf("unexpected sections:\n%s\n%s", strings.Join(sections, ","), expected)
+	}
+
+	if len(report.Modules) != 3 || report.Modules[0].TestID != "test-1" || report.Modules[1].Result != TestResultFailed || report.Modules[2].TestID != "" {
+		t.Errorf("unexpected modules %+v", report.Modules)
+	}
+
+	if cause := report.Modules[1].ProbableCause; cause == nil || cause.Rule != "redirect-uri-mismatch" {
+		t.Errorf("expected a probable cause for the failed module but got %+v", cause)
+	}
+
+	if report.Modules[0].ProbableCause != nil {
+		t.Errorf("expected no probable cause for the passed module")
+	}
+
+	table := &bytes.Buffer{}
+
+	if err = report.WriteTable(table); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(table.String(), "OIDCC") || !strings.Contains(table.String(), "oidcc-refresh-token") {
+		t.Errorf("unexpected table:\n%s", table.String())
+	}
+
+	out := &bytes.Buffer{}
+
+	if err = report.WriteJSON(out); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := CoverageReport{}
+
+	if err = json.Unmarshal(out.Bytes(), &decoded); err != nil || len(decoded.Specs) != 2 {
+		t.Errorf("unexpected json output %s: %v", out.String(), err)
+	}
+}
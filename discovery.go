@@ -0,0 +1,262 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DiscoveryDocument is an OpenID Connect Discovery 1.0 provider metadata document.
+type DiscoveryDocument struct {
+	Issuer                                     string   `json:"issuer"`
+	AuthorizationEndpoint                      string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                              string   `json:"token_endpoint,omitempty"`
+	UserinfoEndpoint                           string   `json:"userinfo_endpoint,omitempty"`
+	JSONWebKeysURI                             string   `json:"jwks_uri,omitempty"`
+	RegistrationEndpoint                       string   `json:"registration_endpoint,omitempty"`
+	EndSessionEndpoint                         string   `json:"end_session_endpoint,omitempty"`
+	PushedAuthorizationRequestEndpoint         string   `json:"pushed_authorization_request_endpoint,omitempty"`
+	ScopesSupported                            []string `json:"scopes_supported,omitempty"`
+	SubjectTypesSupported                      []string `json:"subject_types_supported,omitempty"`
+	ResponseTypesSupported                     []string `json:"response_types_supported,omitempty"`
+	ResponseModesSupported                     []string `json:"response_modes_supported,omitempty"`
+	GrantTypesSupported                        []string `json:"grant_types_supported,omitempty"`
+	TokenEndpointAuthMethodsSupported          []string `json:"token_endpoint_auth_methods_supported,omitempty"`
+	TokenEndpointAuthSigningAlgValuesSupported []string `json:"token_endpoint_auth_signing_alg_values_supported,omitempty"`
+	IDTokenSigningAlgValuesSupported           []string `json:"id_token_signing_alg_values_supported,omitempty"`
+	UserinfoSigningAlgValuesSupported          []string `json:"userinfo_signing_alg_values_supported,omitempty"`
+	RequestObjectSigningAlgValuesSupported     []string `json:"request_object_signing_alg_values_supported,omitempty"`
+	CodeChallengeMethodsSupported              []string `json:"code_challenge_methods_supported,omitempty"`
+	ClaimsSupported                            []string `json:"claims_supported,omitempty"`
+	RequestParameterSupported                  bool     `json:"request_parameter_supported,omitempty"`
+	RequestURIParameterSupported               *bool    `json:"request_uri_parameter_supported,omitempty"`
+	FrontChannelLogoutSupported                bool     `json:"frontchannel_logout_supported,omitempty"`
+	BackChannelLogoutSupported                 bool     `json:"backchannel_logout_supported,omitempty"`
+	RequirePushedAuthorizationRequests         bool     `json:"require_pushed_authorization_requests,omitempty"`
+	DPoPSigningAlgValuesSupported              []string `json:"dpop_signing_alg_values_supported,omitempty"`
+}
+
+// ParseDiscoveryDocument parses a discovery document and checks it has the issuer and the endpoints a static server
+// metadata plan requires.
+func ParseDiscoveryDocument(data []byte) (doc *DiscoveryDocument, err error) {
+	doc = &DiscoveryDocument{}
+
+	if err = json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("error decoding discovery document: %w", err)
+	}
+
+	var errs []error
+
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"issuer", doc.Issuer},
+		{"authorization_endpoint", doc.AuthorizationEndpoint},
+		{"token_endpoint", doc.TokenEndpoint},
+		{"jwks_uri", doc.JSONWebKeysURI},
+	} {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("error decoding discovery document: the '%s' is required", field.name))
+
+			continue
+		}
+
+		if u, err := url.Parse(field.value); err != nil || !u.IsAbs() {
+			errs = append(errs, fmt.Errorf("error decoding discovery document: the '%s' value '%s' is not an absolute url", field.name, field.value))
+		}
+	}
+
+	if err = errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// DiscoveryURL returns the OpenID Connect discovery document URL of the issuer.
+func DiscoveryURL(issuer string) (discoveryURI *url.URL, err error) {
+	if discoveryURI, err = url.ParseRequestURI(issuer); err != nil {
+		return nil, err
+	}
+
+	return discoveryURI.JoinPath(".well-known", "openid-configuration"), nil
+}
+
+// FetchDiscoveryDocument fetches and parses the discovery document of the issuer. If the client is nil the
+// http.DefaultClient is used.
+func FetchDiscoveryDocument(ctx context.Context, client *http.Client, issuer string) (doc *DiscoveryDocument, err error) {
+	var discoveryURI *url.URL
+
+	if discoveryURI, err = DiscoveryURL(issuer); err != nil {
+		return nil, fmt.Errorf("error fetching discovery document: %w", err)
+	}
+
+	var data []byte
+
+	if data, err = fetchJSON(ctx, client, discoveryURI.String(), "discovery document"); err != nil {
+		return nil, err
+	}
+
+	return ParseDiscoveryDocument(data)
+}
+
+// fetchJSON fetches the JSON document at the URI, the name describes the document in errors. If the client is nil the
+// http.DefaultClient is used.
+func fetchJSON(ctx context.Context, client *http.Client, uri, name string) (data []byte, err error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", name, err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", name, err)
+	}
+
+	defer resp.Body.Close()
+
+	if data, err = io.ReadAll(resp.Body); err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", name, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request for the %s '%s' failed with status %d and data: %s", name, uri, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+// PlanServer returns the static server config of a plan for the provider metadata.
+func (d *DiscoveryDocument) PlanServer() *PlanServer {
+	return &PlanServer{
+		Issuer:                d.Issuer,
+		AuthorizationEndpoint: d.AuthorizationEndpoint,
+		TokenEndpoint:         d.TokenEndpoint,
+		UserinfoEndpoint:      d.UserinfoEndpoint,
+		JSONWebKeysURI:        d.JSONWebKeysURI,
+		RegistrationEndpoint:  d.RegistrationEndpoint,
+	}
+}
+
+// UseStaticServerMetadata switches each plan from discovery to the static server metadata of the discovery document,
+// so the suite uses the endpoints from the document instead of fetching the discovery document of the issuer. Any
+// login_hint or acr_values of the plans are kept. Plans which can't use static server metadata are left unchanged.
+func UseStaticServerMetadata(doc *DiscoveryDocument, plans ...*PlanMetadata) {
+	for _, plan := range plans {
+		if plan == nil || plan.Config == nil || !plan.supportsStaticServerMetadata() {
+			continue
+		}
+
+		server := doc.PlanServer()
+
+		if plan.Config.Server != nil {
+			server.LoginHint, server.ACRValues = plan.Config.Server.LoginHint, plan.Config.Server.ACRValues
+		}
+
+		plan.Config.Server = server
+
+		if plan.Variant == nil {
+			plan.Variant = &PlanVariant{}
+		}
+
+		plan.Variant.ServerMetadata = "static"
+	}
+}
+
+// supportsStaticServerMetadata reports whether the plan has the server_metadata variant. The FAPI and relying party
+// plans don't, and the config plan always tests the discovery document of the issuer.
+func (p PlanMetadata) supportsStaticServerMetadata() bool {
+	return !p.IsFAPI() && !p.IsRP() && p.Name != configPlanName
+}
+
+// NewPlanStatic builds a plan like NewPlanDiscovery which uses the static server metadata of the discovery document.
+func NewPlanStatic(name string, variant *PlanVariant, publish Publish, alias, description string, doc *DiscoveryDocument, client, client2, clientSecretPost *PlanClient) (plan *PlanMetadata, err error) {
+	if plan, err = NewPlanDiscovery(name, variant, publish, alias, description, doc.Issuer, client, client2, clientSecretPost); err != nil {
+		return nil, err
+	}
+
+	if plan.Variant != nil {
+		v := *plan.Variant
+		plan.Variant = &v
+	}
+
+	UseStaticServerMetadata(doc, plan)
+
+	return plan, nil
+}
+
+// Constraints returns the variant constraints which skip the combinations using a client_auth_type, response_type, or
+// response_mode the provider does not advertise, each named after the metadata which doesn't list the value. The
+// mtls client_auth_type is allowed if either tls_client_auth or self_signed_tls_client_auth is advertised, and the
+// jarm fapi_response_mode requires the jwt response mode.
+func (d *DiscoveryDocument) Constraints() []VariantConstraint {
+	return []VariantConstraint{
+		{
+			Name: "client_auth_type not in token_endpoint_auth_methods_supported",
+			Allow: func(combination VariantCombination) bool {
+				supported := d.tokenEndpointAuthMethodsSupported()
+
+				switch value := combination["client_auth_type"]; value {
+				case "":
+					return true
+				case "mtls":
+					return slices.Contains(supported, "tls_client_auth") || slices.Contains(supported, "self_signed_tls_client_auth")
+				default:
+					return slices.Contains(supported, value)
+				}
+			},
+		},
+		{
+			Name: "response_type not in response_types_supported",
+			Allow: func(combination VariantCombination) bool {
+				value := combination["response_type"]
+
+				return value == "" || supportsResponseType(d.ResponseTypesSupported, value)
+			},
+		},
+		{
+			Name: "response_mode not in response_modes_supported",
+			Allow: func(combination VariantCombination) bool {
+				supported := d.responseModesSupported()
+
+				if value := combination["response_mode"]; value != "" && value != "default" && !slices.Contains(supported, value) {
+					return false
+				}
+
+				return combination["fapi_response_mode"] != "jarm" || slices.Contains(supported, "jwt")
+			},
+		},
+	}
+}
+
+// tokenEndpointAuthMethodsSupported returns the advertised token endpoint auth methods, or client_secret_basic which
+// is the default when none are advertised.
+func (d *DiscoveryDocument) tokenEndpointAuthMethodsSupported() []string {
+	if len(d.TokenEndpointAuthMethodsSupported) == 0 {
+		return []string{"client_secret_basic"}
+	}
+
+	return d.TokenEndpointAuthMethodsSupported
+}
+
+// responseModesSupported returns the advertised response modes, or the query and fragment response modes which are
+// the default when none are advertised.
+func (d *DiscoveryDocument) responseModesSupported() []string {
+	if len(d.ResponseModesSupported) == 0 {
+		return []string{"query", "fragment"}
+	}
+
+	return d.ResponseModesSupported
+}
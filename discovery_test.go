@@ -0,0 +1,229 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDiscoveryDocument = `{
+  "issuer": "https://idp.example.com",
+  "authorization_endpoint": "https://idp.example.com/api/oidc/authorization",
+  "token_endpoint": "https://idp.example.com/api/oidc/token",
+  "userinfo_endpoint": "https://idp.example.com/api/oidc/userinfo",
+  "jwks_uri": "https://idp.example.com/jwks.json",
+  "registration_endpoint": "https://idp.example.com/api/oidc/register",
+  "response_types_supported": ["code", "id_token"],
+  "token_endpoint_auth_methods_supported": ["client_secret_basic", "private_key_jwt"]
+}`
+
+func TestParseDiscoveryDocument(t *testing.T) {
+	doc, err := ParseDiscoveryDocument([]byte(testDiscoveryDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Issuer != "https://idp.example.com" || doc.JSONWebKeysURI != "https://idp.example.com/jwks.json" || strings.Join(doc.ResponseTypesSupported, ",") != "code,id_token" {
+		t.Errorf("unexpected document %+v", doc)
+	}
+
+	_, err = ParseDiscoveryDocument([]byte(`{"issuer": "https://idp.example.com", "authorization_endpoint": "/authorization"}`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{
+		"the 'authorization_endpoint' value '/authorization' is not an absolute url",
+		"the 'token_endpoint' is required",
+		"the 'jwks_uri' is required",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected the error to contain %q but got: %v", expected, err)
+		}
+	}
+
+	if _, err = ParseDiscoveryDocument([]byte(`[]`)); err == nil {
+		t.Errorf("expected an error decoding an invalid document")
+	}
+}
+
+func TestFetchDiscoveryDocument(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/issuer/.well-known/openid-configuration":
+			_, _ = w.Write([]byte(testDiscoveryDocument))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+
+	defer server.Close()
+
+	doc, err := FetchDiscoveryDocument(context.Background(), server.Client(), server.URL+"/issuer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.TokenEndpoint != "https://idp.example.com/api/oidc/token" {
+		t.Errorf("unexpected document %+v", doc)
+	}
+
+	if _, err = FetchDiscoveryDocument(context.Background(), server.Client(), server.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "failed with status 404") {
+		t.Errorf("expected a status error but got %v", err)
+	}
+}
+
+func TestUseStaticServerMetadata(t *testing.T) {
+	doc, err := ParseDiscoveryDocument([]byte(testDiscoveryDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plans, err := NewPlansAll("https://idp.example.com", "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plans[0].Config.Server.LoginHint = "john"
+
+	fapi, err := NewFAPI2SecurityProfilePlan("fapi", "FAPI", "https://idp.example.com", "", &PlanVariant{ClientAuthType: "private_key_jwt", FAPIProfile: "plain_fapi", SenderConstrain: "dpop", OpenID: "openid_connect"}, nil, NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := NewClientCertificationProfileDynamicPlan("rp", "RP", "client_secret_basic", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	UseStaticServerMetadata(doc, append(plans, fapi, rp)...)
+
+	if fapi.Config.Server.DiscoveryURL == "" || fapi.Config.Server.AuthorizationEndpoint != "" || rp.Config.Server != nil || rp.Variant.ServerMetadata != "" {
+		t.Errorf("expected the FAPI and RP plans to be unchanged but got %+v and %+v", fapi.Config.Server, rp.Variant)
+	}
+
+	for _, plan := range plans {
+		if plan.Name == configPlanName {
+			if plan.Variant != nil || plan.Config.Server.DiscoveryURL == "" {
+				t.Errorf("%s: expected the config plan to keep discovery but got %+v", plan.Config.Alias, plan.Config.Server)
+			}
+
+			continue
+		}
+
+		if plan.Variant.ServerMetadata != "static" {
+			t.Errorf("%s: expected static server metadata but got '%s'", plan.Config.Alias, plan.Variant.ServerMetadata)
+		}
+
+		data, _ := json.Marshal(plan.Config.Server)
+
+		if strings.Contains(string(data), "discoveryUrl") || !strings.Contains(string(data), `"authorization_endpoint":"https://idp.example.com/api/oidc/authorization"`) {
+			t.Errorf("%s: unexpected server %s", plan.Config.Alias, data)
+		}
+	}
+
+	if plans[0].Config.Server.LoginHint != "john" {
+		t.Errorf("expected the login hint to be kept")
+	}
+
+	variant := &PlanVariant{ServerMetadata: "discovery", ClientRegistration: "static_client"}
+
+	plan, err := NewPlanStatic("oidcc-basic-certification-test-plan", variant, NoPublish, "basic", "Basic", doc, &PlanClient{ClientID: "conformance-basic-1"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Variant.ServerMetadata != "static" || variant.ServerMetadata != "discovery" || plan.Config.Server.Issuer != doc.Issuer {
+		t.Errorf("unexpected plan %+v %+v", plan.Variant, plan.Config.Server)
+	}
+
+	dynamic, err := NewCertificationProfileDynamicDiscoveryPlan("dynamic", "Dynamic", doc.Issuer, nil, NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	UseStaticServerMetadata(doc, dynamic)
+
+	if dynamic.Variant.ServerMetadata != "static" || dynamic.Config.Server.RegistrationEndpoint != "https://idp.example.com/api/oidc/register" {
+		t.Errorf("expected the dynamic plan to have the registration endpoint but got %+v", dynamic.Config.Server)
+	}
+}
+
+func TestComprehensiveVariantMatrixDiscovery(t *testing.T) {
+	doc := &DiscoveryDocument{
+		ResponseTypesSupported:            []string{"code", "token id_token"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "tls_client_auth"},
+	}
+
+	matrix := NewComprehensiveVariantMatrixWithOptions("secret", "https://idp.example.com", SummaryPublish, ComprehensiveMatrixOptions{Discovery: doc})
+
+	var combinations []string
+
+	for _, combination := range matrix.Combinations() {
+		combinations = append(combinations, combination.String())
+	}
+
+	expected := []string{
+		"client_auth_type=client_secret_basic,response_mode=default,response_type=code",
+		"client_auth_type=client_secret_basic,response_mode=default,response_type=id_token token",
+		"client_auth_type=tls_client_auth,response_mode=default,response_type=code",
+		"client_auth_type=tls_client_auth,response_mode=default,response_type=id_token token",
+	}
+
+	if strings.Join(combinations, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected combinations %q but got %q", expected, combinations)
+	}
+
+	skipped := matrix.Skipped()
+
+	if len(skipped)+len(combinations) != len(clientAuthTypes)*len(responseTypes)*len(responseModes) {
+		t.Fatalf("expected every other combination to be skipped but got %d", len(skipped))
+	}
+
+	reasons := map[string]string{}
+
+	for _, skip := range skipped {
+		reasons[skip.Combination.String()] = skip.Reason
+	}
+
+	for combination, reason := range map[string]string{
+		"client_auth_type=none,response_mode=default,response_type=code":                    "client_auth_type not in token_endpoint_auth_methods_supported",
+		"client_auth_type=client_secret_basic,response_mode=default,response_type=id_token": "response_type not in response_types_supported",
+		"client_auth_type=client_secret_basic,response_mode=form_post,response_type=code":   "response_mode not in response_modes_supported",
+	} {
+		if reasons[combination] != reason {
+			t.Errorf("expected '%s' to be skipped with reason '%s' but got '%s'", combination, reason, reasons[combination])
+		}
+	}
+}
+
+func TestDiscoveryDocumentConstraints(t *testing.T) {
+	doc := &DiscoveryDocument{
+		ResponseModesSupported:            []string{"query", "jwt"},
+		TokenEndpointAuthMethodsSupported: []string{"private_key_jwt", "self_signed_tls_client_auth"},
+	}
+
+	matrix := &VariantMatrix{Constraints: doc.Constraints()}
+
+	for combination, allowed := range map[string]bool{
+		"client_auth_type=mtls":                                   true,
+		"client_auth_type=client_secret_basic":                    false,
+		"fapi_response_mode=jarm":                                 true,
+		"fapi_response_mode=plain_response,response_mode=default": true,
+		"response_mode=form_post":                                 false,
+	} {
+		c := VariantCombination{}
+
+		for _, pair := range strings.Split(combination, ",") {
+			key, value, _ := strings.Cut(pair, "=")
+			c[key] = value
+		}
+
+		if matrix.Allowed(c) != allowed {
+			t.Errorf("expected '%s' allowed to be %t", combination, allowed)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RPTest is a test instance of a relying party plan which an RPDriver runs the relying party against.
+type RPTest struct {
+	PlanID  string
+	TestID  string
+	Module  string
+	Variant *PlanVariant
+
+	// Issuer is the issuer of the OP the suite runs for the test instance.
+	Issuer string
+
+	// Client is the static client of the plan, which is nil when the relying party registers itself dynamically.
+	Client *PlanClient
+}
+
+// RPDriver runs the relying party under test against the OP of a test instance of a relying party plan, performing
+// whatever the module expects of the relying party such as discovery, registration, and an authorization flow.
+type RPDriver interface {
+	RunRP(ctx context.Context, test RPTest) error
+}
+
+// RPDriverFunc adapts a func to an RPDriver.
+type RPDriverFunc func(ctx context.Context, test RPTest) error
+
+func (f RPDriverFunc) RunRP(ctx context.Context, test RPTest) error {
+	return f(ctx, test)
+}
+
+// RPResult is the outcome of a module run by RunRPPlan.
+type RPResult struct {
+	Module string
+	TestID string
+	Info   *TestInfo
+
+	// Err is the error returned by the driver. Many modules expect the relying party to reject what the suite returns,
+	// so an error doesn't mean the module failed and the result of the Info is authoritative.
+	Err error
+}
+
+// RPRunOptions are the options of RunRPPlan.
+type RPRunOptions struct {
+	// Modules limits the run to these modules of the plan, every module is run if empty.
+	Modules []string
+
+	// Interval is the interval between polls of the test info, if zero the DefaultTailInterval is used.
+	Interval time.Duration
+
+	// FinishTimeout is the maximum time to wait for a test instance to finish after the driver returns, as some
+	// modules only finish once the suite gives up waiting for the relying party. If zero it waits until the test
+	// instance finishes, otherwise the Info of the result is the current info of the unfinished test instance.
+	FinishTimeout time.Duration
+}
+
+// RunRPPlan runs the modules of a relying party plan one at a time. For each module it creates a test instance, waits
+// for the suite to be waiting for the relying party, runs the driver against the issuer of the test instance, and
+// waits for the test instance to finish. If the test instance finishes before the suite waits for the relying party
+// the driver isn't run and the result is the info of the finished test instance. The results of the modules run before
+// an error are returned with the error.
+func (c *APIClient) RunRPPlan(ctx context.Context, planID string, driver RPDriver, opts RPRunOptions) (results []RPResult, err error) {
+	var plan *PlanMetadata
+
+	if plan, err = c.GetPlan(ctx, planID); err != nil {
+		return nil, err
+	}
+
+	if !plan.IsRP() {
+		return nil, fmt.Errorf("plan '%s' is a '%s' plan which is not a relying party plan", plan.ID, plan.Name)
+	}
+
+	alias := ""
+
+	var client *PlanClient
+
+	if plan.Config != nil {
+		alias, client = plan.Config.Alias, plan.Config.Client
+	}
+
+	for _, module := range plan.Modules {
+		if len(opts.Modules) != 0 && !slices.Contains(opts.Modules, module.TestModule) {
+			continue
+		}
+
+		var response *TestCreateResponse
+
+		if response, err = c.CreateTestInstance(ctx, plan.ID, module.TestModule, module.Variant); err != nil {
+			return results, err
+		}
+
+		result := RPResult{Module: module.TestModule, TestID: response.ID}
+
+		if result.Info, err = c.waitForTestWaiting(ctx, response.ID, opts.Interval); err != nil {
+			return results, err
+		}
+
+		if result.Info.Status.Done() {
+			results = append(results, result)
+
+			continue
+		}
+
+		result.Err = driver.RunRP(ctx, RPTest{
+			PlanID:  plan.ID,
+			TestID:  response.ID,
+			Module:  module.TestModule,
+			Variant: module.Variant,
+			Issuer:  c.testIssuer(response, alias),
+			Client:  client,
+		})
+
+		if result.Info, err = c.waitForTestFinish(ctx, response.ID, opts.Interval, opts.FinishTimeout); err != nil {
+			return results, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// waitForTestWaiting polls the info of a test instance until the suite is waiting for the relying party, or the test
+// instance is done.
+func (c *APIClient) waitForTestWaiting(ctx context.Context, testID string, interval time.Duration) (info *TestInfo, err error) {
+	if interval <= 0 {
+		interval = DefaultTailInterval
+	}
+
+	for {
+		if info, err = c.GetTestInfo(ctx, testID); err != nil {
+			return nil, err
+		}
+
+		if info.Status == TestStatusWaiting || info.Status.Done() {
+			return info, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return info, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *APIClient) waitForTestFinish(ctx context.Context, testID string, interval, timeout time.Duration) (info *TestInfo, err error) {
+	if timeout <= 0 {
+		return c.WaitForTest(ctx, testID, interval)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+
+	defer cancel()
+
+	if info, err = c.WaitForTest(waitCtx, testID, interval); err == nil || ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+		return info, err
+	}
+
+	return c.GetTestInfo(ctx, testID)
+}
+
+// testIssuer returns the issuer of the OP the suite runs for a test instance. The suite returns the base URL of the
+// test instance when it is created, otherwise it is derived from the suite root and the alias or test ID.
+func (c *APIClient) testIssuer(response *TestCreateResponse, alias string) string {
+	if response.URL != "" {
+		return response.URL
+	}
+
+	root := *c.root
+
+	root.Path = strings.TrimSuffix(strings.TrimSuffix(root.Path, "/"), "/api")
+
+	if alias != "" {
+		return root.JoinPath("test", "a", alias).String() + "/"
+	}
+
+	return root.JoinPath("test", response.ID).String() + "/"
+}
+
+// CommandRPDriver is an RPDriver which runs a command for every test instance, for a relying party which isn't
+// written in Go. The command is passed the test in the OIDCC_RP_PLAN_ID, OIDCC_RP_TEST_ID, OIDCC_RP_MODULE,
+// OIDCC_RP_VARIANT, OIDCC_RP_ISSUER, OIDCC_RP_CLIENT_ID, OIDCC_RP_CLIENT_SECRET, and OIDCC_RP_REDIRECT_URI environment
+// variables, where the variant is JSON and the client variables are empty for dynamic registration.
+type CommandRPDriver struct {
+	Path string
+	Args []string
+
+	// Env is added to the environment of the current process.
+	Env []string
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (d *CommandRPDriver) RunRP(ctx context.Context, test RPTest) error {
+	variant := []byte("{}")
+
+	if test.Variant != nil {
+		var err error
+
+		if variant, err = json.Marshal(test.Variant); err != nil {
+			return fmt.Errorf("error encoding variant of module '%s': %w", test.Module, err)
+		}
+	}
+
+	client := test.Client
+
+	if client == nil {
+		client = &PlanClient{}
+	}
+
+	cmd := exec.CommandContext(ctx, d.Path, d.Args...)
+
+	cmd.Env = append(append(os.Environ(), d.Env...),
+		"OIDCC_RP_PLAN_ID="+test.PlanID,
+		"OIDCC_RP_TEST_ID="+test.TestID,
+		"OIDCC_RP_MODULE="+test.Module,
+		"OIDCC_RP_VARIANT="+string(variant),
+		"OIDCC_RP_ISSUER="+test.Issuer,
+		"OIDCC_RP_CLIENT_ID="+client.ClientID,
+		"OIDCC_RP_CLIENT_SECRET="+client.ClientSecret,
+		"OIDCC_RP_REDIRECT_URI="+client.RedirectURI,
+	)
+
+	cmd.Stdout, cmd.Stderr = d.Stdout, d.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running the relying party for module '%s': %w", test.Module, err)
+	}
+
+	return nil
+}
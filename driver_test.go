@@ -0,0 +1,103 @@
+package oidcc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunRPPlan(t *testing.T) {
+	s := newFakeSuite(t)
+
+	plan := s.add(PlanMetadata{
+		Name:    "oidcc-client-basic-certification-test-plan",
+		Config:  &PlanConfig{Alias: "rp-basic", Client: &PlanClient{ClientID: "rp", RedirectURI: "https://rp.example.com/callback"}},
+		Modules: []PlanModule{{TestModule: "oidcc-client-test"}, {TestModule: "oidcc-client-test-invalid-iss"}, {TestModule: "oidcc-client-test-nonce-invalid"}, {TestModule: "oidcc-client-test-unsupported"}, {TestModule: "oidcc-client-test-skipped"}},
+	})
+
+	s.runnerResults["oidcc-client-test-unsupported"] = TestResultSkipped
+
+	var tests []RPTest
+
+	driver := RPDriverFunc(func(ctx context.Context, test RPTest) error {
+		tests = append(tests, test)
+
+		switch test.Module {
+		case "oidcc-client-test":
+			s.setResult(test.TestID, TestResultPassed)
+		case "oidcc-client-test-invalid-iss":
+			s.setResult(test.TestID, TestResultPassed)
+
+			return errors.New("invalid issuer")
+		}
+
+		return nil
+	})
+
+	results, err := s.client().RunRPPlan(context.Background(), plan.ID, driver, RPRunOptions{
+		Modules:       []string{"oidcc-client-test", "oidcc-client-test-invalid-iss", "oidcc-client-test-nonce-invalid", "oidcc-client-test-unsupported"},
+		Interval:      time.Millisecond,
+		FinishTimeout: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 4 || len(tests) != 3 {
+		t.Fatalf("expected 4 results and 3 driver runs but got %d and %d", len(results), len(tests))
+	}
+
+	for _, test := range tests {
+		if test.PlanID != plan.ID || test.Issuer != s.server.URL+"/test/a/rp-basic/" || test.Client == nil || test.Client.ClientID != "rp" {
+			t.Errorf("unexpected test %+v", test)
+		}
+	}
+
+	if results[0].Err != nil || results[0].Info.Result != TestResultPassed {
+		t.Errorf("expected the first module to pass but got %+v", results[0])
+	}
+
+	if results[1].Err == nil || results[1].Info.Result != TestResultPassed {
+		t.Errorf("expected the driver error of the second module to be recorded but got %+v", results[1])
+	}
+
+	if results[2].Info.Status != TestStatusWaiting {
+		t.Errorf("expected the third module to still be waiting after the finish timeout but got %+v", results[2].Info)
+	}
+
+	if results[3].Err != nil || results[3].Info.Status != TestStatusFinished || results[3].Info.Result != TestResultSkipped {
+		t.Errorf("expected the fourth module to finish without running the driver but got %+v", results[3])
+	}
+
+	opPlan := s.add(PlanMetadata{Name: "oidcc-basic-certification-test-plan"})
+
+	if _, err = s.client().RunRPPlan(context.Background(), opPlan.ID, driver, RPRunOptions{}); err == nil {
+		t.Errorf("expected an error running an OP plan")
+	}
+}
+
+func TestCommandRPDriver(t *testing.T) {
+	driver := &CommandRPDriver{
+		Path: "sh",
+		Args: []string{"-c", `test "$OIDCC_RP_ISSUER" = "https://suite.example.com/test/a/rp/" && test "$OIDCC_RP_CLIENT_ID" = "rp" && test "$OIDCC_RP_VARIANT" = '{"client_auth_type":"client_secret_basic"}' && test "$EXTRA" = "1"`},
+		Env:  []string{"EXTRA=1"},
+	}
+
+	test := RPTest{
+		Module:  "oidcc-client-test",
+		Variant: &PlanVariant{ClientAuthType: "client_secret_basic"},
+		Issuer:  "https://suite.example.com/test/a/rp/",
+		Client:  &PlanClient{ClientID: "rp"},
+	}
+
+	if err := driver.RunRP(context.Background(), test); err != nil {
+		t.Fatal(err)
+	}
+
+	test.Client = nil
+
+	if err := driver.RunRP(context.Background(), test); err == nil {
+		t.Errorf("expected an error when the command fails")
+	}
+}
@@ -0,0 +1,68 @@
+package oidcc
+
+import (
+	"slices"
+	"strings"
+)
+
+// PlanRegistration is the registration config of a plan which uses dynamic client registration.
+type PlanRegistration struct {
+	// InitialAccessToken is the bearer token the suite sends to the registration endpoint when the endpoint requires
+	// an initial access token.
+	InitialAccessToken string `json:"initial_access_token,omitempty"`
+
+	// Policy is the registration policy of the identity provider. It is not sent to the suite.
+	Policy *RegistrationPolicy `json:"-"`
+}
+
+// RegistrationPolicy describes the client metadata the registration endpoint of the identity provider accepts. An
+// empty list accepts every value.
+type RegistrationPolicy struct {
+	TokenEndpointAuthMethods []string
+	ResponseTypes            []string
+	ResponseModes            []string
+}
+
+// Allows reports whether the registration endpoint accepts a client registered for the combination.
+func (p *RegistrationPolicy) Allows(combination VariantCombination) bool {
+	if p == nil {
+		return true
+	}
+
+	allowed := func(values []string, value string) bool {
+		return value == "" || len(values) == 0 || slices.Contains(values, value)
+	}
+
+	responseMode := combination["response_mode"]
+
+	if responseMode == "default" {
+		responseMode = ""
+	}
+
+	return allowed(p.TokenEndpointAuthMethods, combination["client_auth_type"]) &&
+		allowed(p.ResponseTypes, combination["response_type"]) &&
+		allowed(p.ResponseModes, responseMode)
+}
+
+// NewCertificationProfileDynamicDiscoveryPlan builds the Dynamic OP certification profile plan. The suite registers
+// its own clients so the plan has no static clients, and the registration config is used for the registration
+// requests.
+func NewCertificationProfileDynamicDiscoveryPlan(alias, description, issuer string, registration *PlanRegistration, publish Publish) (plan *PlanMetadata, err error) {
+	variant := &PlanVariant{
+		ServerMetadata:     "discovery",
+		ClientRegistration: "dynamic_client",
+	}
+
+	if plan, err = NewPlanDiscovery("oidcc-dynamic-certification-test-plan", variant, publish, alias, description, issuer, nil, nil, nil); err != nil {
+		return nil, err
+	}
+
+	plan.Config.Registration = registration
+
+	return plan, nil
+}
+
+// IsDynamic reports whether the suite registers the clients of the plan using dynamic client registration.
+func (p PlanMetadata) IsDynamic() bool {
+	return (p.Variant != nil && p.Variant.ClientRegistration == "dynamic_client") || strings.HasPrefix(p.Name, "oidcc-dynamic-")
+}
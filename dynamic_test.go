@@ -0,0 +1,90 @@
+package oidcc
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewCertificationProfileDynamicDiscoveryPlan(t *testing.T) {
+	registration := &PlanRegistration{InitialAccessToken: "token", Policy: &RegistrationPolicy{ResponseTypes: []string{"code"}}}
+
+	plan, err := NewCertificationProfileDynamicDiscoveryPlan("certification-profile-dynamic", "Certification Profile: Dynamic", "https://idp.example.com", registration, NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plan.Name != "oidcc-dynamic-certification-test-plan" || plan.Variant.ClientRegistration != "dynamic_client" || !plan.IsDynamic() {
+		t.Errorf("unexpected plan %s %+v", plan.Name, plan.Variant)
+	}
+
+	if plan.Config.Client != nil || plan.Config.Client2 != nil || plan.Config.ClientSecretPost != nil {
+		t.Errorf("expected no static clients")
+	}
+
+	data, err := json.Marshal(plan.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"registration":{"initial_access_token":"token"}`) || strings.Contains(string(data), "Policy") {
+		t.Errorf("unexpected config %s", data)
+	}
+
+	if clients := plan.GetClients(&url.URL{Scheme: "https", Host: "suite.example.com"}); len(clients) != 0 {
+		t.Errorf("expected no identity provider clients but got %d", len(clients))
+	}
+}
+
+func TestComprehensiveVariantMatrixDynamicClients(t *testing.T) {
+	static := NewComprehensiveVariantMatrix("secret", "https://idp.example.com", NoPublish).Combinations()
+
+	for _, combination := range static {
+		if _, ok := combination["client_registration"]; ok {
+			t.Fatalf("expected no client_registration dimension by default")
+		}
+	}
+
+	registration := &PlanRegistration{
+		InitialAccessToken: "token",
+		Policy:             &RegistrationPolicy{TokenEndpointAuthMethods: []string{"client_secret_basic"}, ResponseTypes: []string{"code", "id_token"}, ResponseModes: []string{"form_post"}},
+	}
+
+	matrix := NewComprehensiveVariantMatrixWithOptions("secret", "https://idp.example.com", NoPublish, ComprehensiveMatrixOptions{DynamicClients: true, Registration: registration})
+
+	plans, err := matrix.Plans()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dynamic []*PlanMetadata
+
+	for _, plan := range plans {
+		if plan.IsDynamic() {
+			dynamic = append(dynamic, plan)
+		}
+	}
+
+	if len(plans)-len(dynamic) != len(static) {
+		t.Errorf("expected %d static plans but got %d", len(static), len(plans)-len(dynamic))
+	}
+
+	if len(dynamic) != 4 {
+		t.Fatalf("expected 4 dynamic plans allowed by the policy but got %d", len(dynamic))
+	}
+
+	for _, plan := range dynamic {
+		if plan.Variant.ClientAuthType != "client_secret_basic" || plan.Config.Client != nil || plan.Config.Registration != registration {
+			t.Errorf("%s: unexpected dynamic plan %+v", plan.Config.Alias, plan.Variant)
+		}
+
+		if !strings.HasSuffix(plan.Config.Alias, "-dynamic") || !strings.HasSuffix(plan.Config.Description, " Dynamic") {
+			t.Errorf("unexpected alias '%s' or description '%s'", plan.Config.Alias, plan.Config.Description)
+		}
+	}
+
+	if dynamic[0].Config.Alias != "conformance-basic-code-dynamic" || dynamic[1].Config.Alias != "conformance-basic-codeformpost-dynamic" {
+		t.Errorf("unexpected aliases %s %s", dynamic[0].Config.Alias, dynamic[1].Config.Alias)
+	}
+}
@@ -0,0 +1,314 @@
+package oidcc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultClientIDPrefix is the prefix of the client ID of every client generated for a plan.
+const DefaultClientIDPrefix = "conformance-"
+
+type ClientData struct {
+	IdentityProviders ClientDataIdentityProviders `yaml:"identity_providers"`
+}
+
+type ClientDataIdentityProviders struct {
+	OpenIDConnect ClientDataIdentityProvidersOpenIDConnect `yaml:"oidc"`
+}
+
+type ClientDataIdentityProvidersOpenIDConnect struct {
+	Clients []Client `yaml:"clients"`
+}
+
+func NewClientData(clients []Client) *ClientData {
+	return &ClientData{IdentityProviders: ClientDataIdentityProviders{OpenIDConnect: ClientDataIdentityProvidersOpenIDConnect{Clients: clients}}}
+}
+
+// RenderClients validates the clients and renders the identity_providers.oidc.clients section of an Authelia
+// configuration for them.
+func RenderClients(clients []Client) (data []byte, err error) {
+	if err = ValidateClients(clients); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+
+	encoder := yaml.NewEncoder(buf)
+
+	encoder.SetIndent(2)
+
+	if err = encoder.Encode(NewClientData(clients)); err != nil {
+		return nil, fmt.Errorf("error rendering clients: %w", err)
+	}
+
+	if err = encoder.Close(); err != nil {
+		return nil, fmt.Errorf("error rendering clients: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MergeClients merges the clients into the identity_providers.oidc.clients section of an existing Authelia
+// configuration after validating them. Existing clients with a client ID starting with the prefix are replaced in
+// place by the client with the same ID or removed if there is no such client, the remaining clients are appended, and
+// every other client, key, and comment is retained. An empty prefix is the DefaultClientIDPrefix.
+func MergeClients(configuration []byte, clients []Client, prefix string) (data []byte, err error) {
+	if prefix == "" {
+		prefix = DefaultClientIDPrefix
+	}
+
+	if err = ValidateClients(clients); err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{}
+
+	if err = yaml.Unmarshal(configuration, doc); err != nil {
+		return nil, fmt.Errorf("error parsing configuration: %w", err)
+	}
+
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("error parsing configuration: the configuration is not a mapping")
+	}
+
+	var section *yaml.Node
+
+	if section, err = yamlMappingPath(doc.Content[0], yaml.SequenceNode, "identity_providers", "oidc", "clients"); err != nil {
+		return nil, err
+	}
+
+	generated := map[string]*yaml.Node{}
+	order := make([]string, 0, len(clients))
+
+	for _, client := range clients {
+		node := &yaml.Node{}
+
+		if err = node.Encode(client); err != nil {
+			return nil, fmt.Errorf("error encoding client '%s': %w", client.ClientID, err)
+		}
+
+		generated[client.ClientID] = node
+		order = append(order, client.ClientID)
+	}
+
+	content := make([]*yaml.Node, 0, len(section.Content)+len(clients))
+
+	for _, item := range section.Content {
+		id := yamlMappingValue(item, "client_id")
+
+		if !strings.HasPrefix(id, prefix) {
+			content = append(content, item)
+
+			continue
+		}
+
+		if node, ok := generated[id]; ok {
+			node.HeadComment, node.LineComment, node.FootComment = item.HeadComment, item.LineComment, item.FootComment
+
+			content = append(content, node)
+
+			delete(generated, id)
+		}
+	}
+
+	for _, id := range order {
+		if node, ok := generated[id]; ok {
+			content = append(content, node)
+
+			delete(generated, id)
+		}
+	}
+
+	section.Content = content
+	section.Style = 0
+
+	buf := &bytes.Buffer{}
+
+	encoder := yaml.NewEncoder(buf)
+
+	encoder.SetIndent(2)
+
+	if err = encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("error rendering configuration: %w", err)
+	}
+
+	if err = encoder.Close(); err != nil {
+		return nil, fmt.Errorf("error rendering configuration: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MergeClientsFile merges the clients into the Authelia configuration file at path using MergeClients and atomically
+// replaces the file with the result. The file is created if it does not exist.
+func MergeClientsFile(path string, clients []Client, prefix string) (err error) {
+	var (
+		configuration []byte
+		mode          fs.FileMode = 0600
+		info          fs.FileInfo
+	)
+
+	path = filepath.Clean(path)
+
+	switch info, err = os.Stat(path); {
+	case err == nil:
+		mode = info.Mode().Perm()
+
+		if configuration, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("error reading configuration: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("error reading configuration: %w", err)
+	}
+
+	var data []byte
+
+	if data, err = MergeClients(configuration, clients, prefix); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data, mode)
+}
+
+// ClientSecretDigests returns the client_secret of each client in the identity_providers.oidc.clients section of an
+// existing Authelia configuration by client ID, for the SecretDigests of the ClientOptions.
+func ClientSecretDigests(configuration []byte) (digests map[string]string, err error) {
+	var data struct {
+		IdentityProviders struct {
+			OpenIDConnect struct {
+				Clients []struct {
+					ClientID     string `yaml:"client_id"`
+					ClientSecret string `yaml:"client_secret"`
+				} `yaml:"clients"`
+			} `yaml:"oidc"`
+		} `yaml:"identity_providers"`
+	}
+
+	if err = yaml.Unmarshal(configuration, &data); err != nil {
+		return nil, fmt.Errorf("error parsing configuration: %w", err)
+	}
+
+	digests = map[string]string{}
+
+	for _, client := range data.IdentityProviders.OpenIDConnect.Clients {
+		if client.ClientID != "" && client.ClientSecret != "" {
+			digests[client.ClientID] = client.ClientSecret
+		}
+	}
+
+	return digests, nil
+}
+
+// ClientSecretDigestsFile returns the client_secret digests of the Authelia configuration file at path using
+// ClientSecretDigests. A file which does not exist has no clients.
+func ClientSecretDigestsFile(path string) (digests map[string]string, err error) {
+	var configuration []byte
+
+	if configuration, err = os.ReadFile(filepath.Clean(path)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+
+		return nil, fmt.Errorf("error reading configuration: %w", err)
+	}
+
+	return ClientSecretDigests(configuration)
+}
+
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+
+	if err = f.Sync(); err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("error writing configuration: %w", err)
+	}
+
+	return nil
+}
+
+// yamlMappingPath returns the node at the path of keys below the mapping node, creating any missing mappings along the
+// way and a node of the kind for the final key.
+func yamlMappingPath(node *yaml.Node, kind yaml.Kind, keys ...string) (*yaml.Node, error) {
+	for i, key := range keys {
+		want := yaml.MappingNode
+		if i == len(keys)-1 {
+			want = kind
+		}
+
+		var value *yaml.Node
+
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				value = node.Content[j+1]
+
+				break
+			}
+		}
+
+		switch {
+		case value == nil:
+			value = &yaml.Node{Kind: want}
+
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+		case value.Kind == yaml.ScalarNode && value.Tag == "!!null":
+			value.Kind, value.Tag, value.Value = want, "", ""
+		case value.Kind != want:
+			return nil, fmt.Errorf("error parsing configuration: the '%s' key has an unexpected type", strings.Join(keys[:i+1], "."))
+		}
+
+		node = value
+	}
+
+	return node, nil
+}
+
+func yamlMappingValue(node *yaml.Node, key string) string {
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,157 @@
+package oidcc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testConfiguration = `# Authelia configuration.
+server:
+  address: 'tcp://:9091' # listen address
+
+identity_providers:
+  oidc:
+    hmac_secret: 'a-secret'
+    clients:
+      # The production client.
+      - client_id: 'app'
+        client_secret: '$plaintext$app'
+      # Kept but regenerated.
+      - client_id: 'conformance-basic-1'
+        client_secret: '$plaintext$old'
+      - client_id: 'conformance-stale-1'
+        client_secret: '$plaintext$stale'
+`
+
+// newTestClient returns a valid client which is public if the secret is empty.
+func newTestClient(id, secret string) Client {
+	client := Client{
+		ClientID:                id,
+		ClientSecret:            secret,
+		RedirectURIs:            []string{"https://suite.example.com/test/a/" + id + "/callback"},
+		Scopes:                  []string{"openid"},
+		GrantTypes:              []string{"authorization_code"},
+		ResponseTypes:           []string{"code"},
+		AuthorizationPolicy:     "one_factor",
+		TokenEndpointAuthMethod: "client_secret_basic",
+	}
+
+	if secret == "" {
+		client.Public, client.TokenEndpointAuthMethod = true, "none"
+	}
+
+	return client
+}
+
+func TestMergeClients(t *testing.T) {
+	clients := []Client{
+		newTestClient("conformance-new-1", "$plaintext$new"),
+		newTestClient("conformance-basic-1", "$plaintext$basic"),
+	}
+
+	data, err := MergeClients([]byte(testConfiguration), clients, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(data)
+
+	for _, expected := range []string{"# Authelia configuration.", "# listen address", "# The production client.", "# Kept but regenerated.", "hmac_secret: 'a-secret'"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected the merged configuration to retain %q:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "conformance-stale-1") || strings.Contains(out, "$plaintext$old") {
+		t.Errorf("expected stale clients to be removed:\n%s", out)
+	}
+
+	decoded := ClientData{}
+
+	if err = yaml.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+
+	for _, client := range decoded.IdentityProviders.OpenIDConnect.Clients {
+		ids = append(ids, client.ClientID+"="+client.ClientSecret)
+	}
+
+	if strings.Join(ids, ",") != "app=$plaintext$app,conformance-basic-1=$plaintext$basic,conformance-new-1=$plaintext$new" {
+		t.Errorf("unexpected clients %v", ids)
+	}
+}
+
+func TestMergeClientsEmptyConfiguration(t *testing.T) {
+	clients := []Client{newTestClient("conformance-basic-1", "")}
+
+	merged, err := MergeClients(nil, clients, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rendered, err := RenderClients(clients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(merged) != string(rendered) {
+		t.Errorf("expected merging into an empty configuration to match the rendered clients:\n%s\n%s", merged, rendered)
+	}
+
+	if _, err = MergeClients(nil, []Client{{ClientID: "conformance-invalid-1"}}, ""); err == nil {
+		t.Errorf("expected an error merging an invalid client")
+	}
+
+	if _, err = MergeClients([]byte("identity_providers:\n  oidc:\n    clients: 'nope'\n"), clients, ""); err == nil {
+		t.Errorf("expected an error when the clients key is not a sequence")
+	}
+}
+
+func TestMergeClientsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "configuration.yml")
+
+	if err := os.WriteFile(path, []byte(testConfiguration), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MergeClientsFile(path, nil, DefaultClientIDPrefix); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected the file mode to be retained but got %v", info.Mode().Perm())
+	}
+
+	data, _ := os.ReadFile(path)
+
+	if strings.Contains(string(data), "conformance-") || !strings.Contains(string(data), "client_id: 'app'") {
+		t.Errorf("unexpected merged file:\n%s", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+
+	if len(entries) != 1 {
+		t.Errorf("expected the temporary file to be removed but found %d files", len(entries))
+	}
+
+	created := filepath.Join(t.TempDir(), "new.yml")
+
+	if err = MergeClientsFile(created, []Client{newTestClient("conformance-basic-1", "")}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ = os.ReadFile(created); !strings.Contains(string(data), "conformance-basic-1") {
+		t.Errorf("unexpected created file:\n%s", data)
+	}
+}
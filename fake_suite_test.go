@@ -0,0 +1,375 @@
+package oidcc
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSuite is an in-process stand in for the parts of the conformance suite API used by the APIClient.
+type fakeSuite struct {
+	mu sync.Mutex
+
+	server *httptest.Server
+	plans  []*PlanMetadata
+	nextID int
+	logs   map[string][]LogEntry
+	infos  map[string]TestInfo
+
+	// onGetLog is called with the test ID after each request for a log has been served.
+	onGetLog func(testID string)
+
+	// failCreate causes POST /api/plan to fail for any plan with a matching alias.
+	failCreate map[string]bool
+
+	// runnerResults finishes the test instances of the modules with the result as soon as they are created, instead of
+	// waiting for the relying party.
+	runnerResults map[string]TestResult
+
+	// creates and deletes record the ID of every plan created or deleted.
+	creates []string
+	deletes []string
+
+	// createRequests records the query and body of every request to create a plan.
+	createRequests []fakeRequest
+}
+
+type fakeRequest struct {
+	query url.Values
+	body  []byte
+}
+
+func newFakeSuite(t *testing.T) *fakeSuite {
+	t.Helper()
+
+	s := &fakeSuite{failCreate: map[string]bool{}, runnerResults: map[string]TestResult{}, logs: map[string][]LogEntry{}, infos: map[string]TestInfo{}}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/plan", s.handleGetPlans)
+	mux.HandleFunc("POST /api/plan", s.handlePostPlan)
+	mux.HandleFunc("GET /api/plan/{id}", s.handleGetPlan)
+	mux.HandleFunc("DELETE /api/plan/{id}", s.handleDeletePlan)
+	mux.HandleFunc("GET /api/log/{id}", s.handleGetLog)
+	mux.HandleFunc("GET /api/info/{id}", s.handleGetInfo)
+	mux.HandleFunc("POST /api/runner", s.handleRunner)
+	mux.HandleFunc("GET /api/log/{id}/images", s.handleGetImages)
+	mux.HandleFunc("POST /api/log/{id}/images/{placeholder}", s.handlePostImage)
+
+	s.server = httptest.NewTLSServer(mux)
+
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *fakeSuite) client() *APIClient {
+	root, _ := url.Parse(s.server.URL + "/api")
+
+	return NewAPIClient(root, nil, &tls.Config{InsecureSkipVerify: true})
+}
+
+func (s *fakeSuite) add(plan PlanMetadata) *PlanMetadata {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+
+	plan.ID = fmt.Sprintf("plan-%d", s.nextID)
+
+	s.plans = append(s.plans, &plan)
+
+	return &plan
+}
+
+func (s *fakeSuite) plan(id string) *PlanMetadata {
+	for _, plan := range s.plans {
+		if plan.ID == id {
+			return plan
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeSuite) handleGetPlans(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+
+	search := query.Get("search")
+	start, _ := strconv.Atoi(query.Get("start"))
+	length, _ := strconv.Atoi(query.Get("length"))
+
+	var filtered []PlanMetadata
+
+	for _, plan := range s.plans {
+		if search != "" && !strings.Contains(plan.Description, search) && !strings.Contains(plan.Name, search) && (plan.Config == nil || !strings.Contains(plan.Config.Alias, search)) {
+			continue
+		}
+
+		filtered = append(filtered, *plan)
+	}
+
+	response := PlanMetadataResponse{RecordsTotal: len(s.plans), RecordsFiltered: len(filtered)}
+
+	if start < len(filtered) {
+		end := len(filtered)
+
+		if length != 0 && start+length < end {
+			end = start + length
+		}
+
+		response.Data = filtered[start:end]
+	}
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (s *fakeSuite) handlePostPlan(w http.ResponseWriter, r *http.Request) {
+	plan := PlanMetadata{Name: r.URL.Query().Get("planName"), Config: &PlanConfig{}}
+
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "unexpected content type", http.StatusUnsupportedMediaType)
+
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.createRequests = append(s.createRequests, fakeRequest{query: r.URL.Query(), body: body})
+	s.mu.Unlock()
+
+	if err := json.Unmarshal(body, plan.Config); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	if variant := r.URL.Query().Get("variant"); variant != "" {
+		plan.Variant = &PlanVariant{}
+
+		if err := json.Unmarshal([]byte(variant), plan.Variant); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	if plan.Name == "" || s.failCreate[plan.Config.Alias] {
+		http.Error(w, `{"error":"plan could not be created"}`, http.StatusBadRequest)
+
+		return
+	}
+
+	plan.Description = plan.Config.Description
+
+	created := s.add(plan)
+
+	s.mu.Lock()
+	s.creates = append(s.creates, created.ID)
+	s.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(PlanCreateResponse{ID: created.ID, Name: created.Name, Modules: created.Modules})
+}
+
+func (s *fakeSuite) handleGetPlan(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan := s.plan(r.PathValue("id"))
+	if plan == nil {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(plan)
+}
+
+func (s *fakeSuite) handleDeletePlan(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+
+	for i, plan := range s.plans {
+		if plan.ID == id {
+			s.plans = append(s.plans[:i], s.plans[i+1:]...)
+			s.deletes = append(s.deletes, id)
+
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *fakeSuite) log(testID string, entries ...LogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		entry.TestID = testID
+
+		if entry.ID == "" {
+			entry.ID = fmt.Sprintf("%s-%d", testID, len(s.logs[testID]))
+		}
+
+		s.logs[testID] = append(s.logs[testID], entry)
+	}
+}
+
+func (s *fakeSuite) handleGetLog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+
+	entries := []LogEntry{}
+
+	for _, entry := range s.logs[r.PathValue("id")] {
+		if entry.Time > since {
+			entries = append(entries, entry)
+		}
+	}
+
+	hook := s.onGetLog
+
+	s.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(entries)
+
+	if hook != nil {
+		hook(r.PathValue("id"))
+	}
+}
+
+func (s *fakeSuite) setStatus(testID string, status TestStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := s.infos[testID]
+
+	info.TestID, info.Status = testID, status
+
+	s.infos[testID] = info
+}
+
+func (s *fakeSuite) setInfo(info TestInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.infos[info.TestID] = info
+}
+
+func (s *fakeSuite) setResult(testID string, result TestResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := s.infos[testID]
+
+	info.TestID, info.Status, info.Result = testID, TestStatusFinished, result
+
+	s.infos[testID] = info
+}
+
+func (s *fakeSuite) handleGetInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := s.infos[r.PathValue("id")]
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(info)
+}
+
+// handleRunner creates a test instance which is immediately waiting, as the test instances of relying party plans are,
+// unless the module has a result in the runnerResults.
+func (s *fakeSuite) handleRunner(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+
+	if s.plan(query.Get("plan")) == nil {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	s.nextID++
+
+	id := fmt.Sprintf("test-%d", s.nextID)
+
+	s.infos[id] = TestInfo{TestID: id, TestName: query.Get("test"), PlanID: query.Get("plan"), Status: TestStatusWaiting}
+
+	if result, ok := s.runnerResults[query.Get("test")]; ok {
+		s.infos[id] = TestInfo{TestID: id, TestName: query.Get("test"), PlanID: query.Get("plan"), Status: TestStatusFinished, Result: result}
+	}
+
+	_ = json.NewEncoder(w).Encode(TestCreateResponse{ID: id, Name: query.Get("test")})
+}
+
+func (s *fakeSuite) handleGetImages(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := []LogEntry{}
+
+	for _, entry := range s.logs[r.PathValue("id")] {
+		if _, ok := entry.Extra["img"]; ok || entry.Upload != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func (s *fakeSuite) handlePostImage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+
+	if r.Header.Get("Content-Type") != "text/plain" || !strings.HasPrefix(string(body), "data:image/") {
+		http.Error(w, "expected an image data url", http.StatusBadRequest)
+
+		return
+	}
+
+	entries := s.logs[r.PathValue("id")]
+
+	for i := range entries {
+		if entries[i].Upload == r.PathValue("placeholder") {
+			img, _ := json.Marshal(string(body))
+
+			if entries[i].Extra == nil {
+				entries[i].Extra = map[string]json.RawMessage{}
+			}
+
+			entries[i].Extra["img"] = img
+
+			_ = json.NewEncoder(w).Encode(entries[i])
+
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
@@ -0,0 +1,274 @@
+package oidcc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// FAPISigningAlg is the algorithm of the client keys generated for the FAPI plans and of the signed responses the
+	// FAPI clients are registered for.
+	FAPISigningAlg = "PS256"
+
+	// DefaultResourcePath is the path below the issuer of the protected resource the FAPI plans access, which is the
+	// Authelia userinfo endpoint.
+	DefaultResourcePath = "api/oidc/userinfo"
+
+	fapi1AdvancedPlanName        = "fapi1-advanced-final-test-plan"
+	fapi2SecurityProfilePlanName = "fapi2-security-profile-final-test-plan"
+	fapi2MessageSigningPlanName  = "fapi2-message-signing-final-test-plan"
+
+	fapiClientScope              = "openid"
+	fapiPlainOAuthClientScope    = "profile"
+	fapiCertificateAuthorityName = "go-oidcc conformance FAPI client CA"
+)
+
+var (
+	fapiClientAuthTypes     = []string{"private_key_jwt", "mtls"}
+	fapiResponseModes       = []string{"plain_response", "jarm"}
+	fapi1AuthRequestMethods = []string{"by_value", "pushed"}
+	fapiProfiles            = []string{"plain_fapi"}
+	fapi2SenderConstrains   = []string{"dpop", "mtls"}
+	fapi2OpenID             = []string{"openid_connect", "plain_oauth"}
+)
+
+// IsFAPI reports whether the plan is a FAPI plan.
+func (p PlanMetadata) IsFAPI() bool {
+	return strings.HasPrefix(p.Name, "fapi")
+}
+
+// fapiResponseTypes returns the response types of a FAPI plan. JARM and FAPI 2.0 use the code response type, while
+// FAPI 1.0 without JARM uses the hybrid flow to protect the authorization response with the id_token.
+func (p PlanMetadata) fapiResponseTypes() []string {
+	if p.Name != fapi1AdvancedPlanName || (p.Variant != nil && p.Variant.FAPIResponseMode == "jarm") {
+		return []string{"code"}
+	}
+
+	return []string{"code id_token"}
+}
+
+// applyFAPI adjusts a client generated for a FAPI plan to the requirements of the profile: signed request objects and
+// responses using the FAPISigningAlg, JARM when the plan uses it, PKCE with pushed authorization requests when the
+// plan uses PAR which FAPI 2.0 always does, and access tokens bound to the sender constraining method of the plan or to
+// the client certificate as FAPI 1.0 Advanced always requires.
+func (p PlanMetadata) applyFAPI(client *Client) {
+	if !p.IsFAPI() {
+		return
+	}
+
+	client.RequestObjectSigningAlg = FAPISigningAlg
+	client.IDTokenSignedResponseAlg = FAPISigningAlg
+
+	if p.Name == fapi1AdvancedPlanName {
+		client.TLSClientCertificateBoundTokens = true
+	}
+
+	if p.Variant == nil {
+		return
+	}
+
+	if p.Variant.FAPIResponseMode == "jarm" {
+		client.AuthorizationSignedResponseAlg = FAPISigningAlg
+	}
+
+	if p.Variant.FAPIAuthRequestMethod == "pushed" || strings.HasPrefix(p.Name, "fapi2") {
+		client.RequirePushedAuthorizationRequests = true
+		client.RequirePKCE = true
+		client.PKCEChallengeMethod = "S256"
+	}
+
+	switch p.Variant.SenderConstrain {
+	case "dpop":
+		client.DPoPBoundAccessTokens = true
+	case "mtls":
+		client.TLSClientCertificateBoundTokens = true
+	}
+}
+
+// NewFAPI1AdvancedPlan builds a FAPI 1.0 Advanced Final plan for the variant, which must set the client_auth_type,
+// fapi_profile, fapi_response_mode, and fapi_auth_request_method. Both clients get a FAPISigningAlg key to sign
+// request objects and a client certificate issued by the CA for certificate bound access tokens, which is also used to
+// authenticate when the client_auth_type is mtls. If the resource URL is empty the DefaultResourcePath below the
+// issuer is used. The CA is required.
+func NewFAPI1AdvancedPlan(alias, description, issuer, resourceURL string, variant *PlanVariant, ca *CertificateAuthority, publish Publish) (plan *PlanMetadata, err error) {
+	if ca == nil {
+		return nil, fmt.Errorf("error building plan '%s': a certificate authority is required for certificate bound access tokens", alias)
+	}
+
+	return newFAPIPlan(fapi1AdvancedPlanName, alias, description, issuer, resourceURL, variant, ca, publish)
+}
+
+// NewFAPI2SecurityProfilePlan builds a FAPI 2.0 Security Profile Final plan for the variant, which must set the
+// client_auth_type, fapi_profile, sender_constrain, and openid. Both clients get a FAPISigningAlg key. The CA issues
+// the client certificates and is required when either the client_auth_type or the sender_constrain is mtls. If the
+// resource URL is empty the DefaultResourcePath below the issuer is used.
+func NewFAPI2SecurityProfilePlan(alias, description, issuer, resourceURL string, variant *PlanVariant, ca *CertificateAuthority, publish Publish) (plan *PlanMetadata, err error) {
+	return newFAPI2Plan(fapi2SecurityProfilePlanName, alias, description, issuer, resourceURL, variant, ca, publish)
+}
+
+// NewFAPI2MessageSigningPlan builds a FAPI 2.0 Message Signing Final plan like NewFAPI2SecurityProfilePlan, where the
+// variant also sets the fapi_request_method and fapi_response_mode.
+func NewFAPI2MessageSigningPlan(alias, description, issuer, resourceURL string, variant *PlanVariant, ca *CertificateAuthority, publish Publish) (plan *PlanMetadata, err error) {
+	return newFAPI2Plan(fapi2MessageSigningPlanName, alias, description, issuer, resourceURL, variant, ca, publish)
+}
+
+func newFAPI2Plan(name, alias, description, issuer, resourceURL string, variant *PlanVariant, ca *CertificateAuthority, publish Publish) (plan *PlanMetadata, err error) {
+	if variant == nil {
+		return nil, fmt.Errorf("error building plan '%s': the variant is required", alias)
+	}
+
+	mtls := variant.ClientAuthType == "mtls" || variant.SenderConstrain == "mtls"
+
+	switch {
+	case !mtls:
+		ca = nil
+	case ca == nil:
+		return nil, fmt.Errorf("error building plan '%s': a certificate authority is required for mtls", alias)
+	}
+
+	return newFAPIPlan(name, alias, description, issuer, resourceURL, variant, ca, publish)
+}
+
+func newFAPIPlan(name, alias, description, issuer, resourceURL string, variant *PlanVariant, ca *CertificateAuthority, publish Publish) (plan *PlanMetadata, err error) {
+	scope := fapiClientScope
+
+	if variant != nil && variant.OpenID == "plain_oauth" {
+		scope = fapiPlainOAuthClientScope
+	}
+
+	clients := []*PlanClient{
+		{ClientID: "conformance-" + alias + "-1", Scope: scope},
+		{ClientID: "conformance-" + alias + "-2", Scope: scope},
+	}
+
+	for _, client := range clients {
+		if err = client.GenerateKey(FAPISigningAlg); err != nil {
+			return nil, err
+		}
+	}
+
+	if plan, err = NewPlanDiscovery(name, variant, publish, alias, description, issuer, clients[0], clients[1], nil); err != nil {
+		return nil, err
+	}
+
+	if ca != nil {
+		if plan.Config.MTLS, err = ca.IssueClientCertificate(clients[0].ClientID); err != nil {
+			return nil, err
+		}
+
+		if plan.Config.MTLS2, err = ca.IssueClientCertificate(clients[1].ClientID); err != nil {
+			return nil, err
+		}
+	}
+
+	if resourceURL == "" {
+		var issuerURI *url.URL
+
+		if issuerURI, err = url.ParseRequestURI(issuer); err != nil {
+			return nil, err
+		}
+
+		resourceURL = issuerURI.JoinPath(DefaultResourcePath).String()
+	}
+
+	plan.Config.Resource = &PlanResource{ResourceURL: resourceURL}
+
+	return plan, nil
+}
+
+// NewFAPI1AdvancedVariantMatrix returns the matrix of FAPI 1.0 Advanced Final plans over the client authentication
+// types, response modes, and request object methods for the plain_fapi profile. Other profiles can be tested by
+// adding them to the fapi_profile dimension. The plans share a certificate authority which is generated when the first
+// plan is built.
+func NewFAPI1AdvancedVariantMatrix(issuer string, publish Publish) *VariantMatrix {
+	var ca *CertificateAuthority
+
+	return &VariantMatrix{
+		Dimensions: []VariantDimension{
+			{Name: "fapi_profile", Values: fapiProfiles},
+			{Name: "client_auth_type", Values: fapiClientAuthTypes},
+			{Name: "fapi_response_mode", Values: fapiResponseModes},
+			{Name: "fapi_auth_request_method", Values: fapi1AuthRequestMethods},
+		},
+		Alias:       `fapi1-{{ replace .client_auth_type "_" "-" }}-{{ replace .fapi_response_mode "_" "-" }}-{{ replace .fapi_auth_request_method "_" "-" }}{{ if ne .fapi_profile "plain_fapi" }}-{{ replace .fapi_profile "_" "-" }}{{ end }}`,
+		Description: `FAPI1 Advanced: {{ clientAuthTypeDescription .client_auth_type }}{{ if eq .fapi_response_mode "jarm" }} JARM{{ end }}{{ if eq .fapi_auth_request_method "pushed" }} PAR{{ end }}{{ if ne .fapi_profile "plain_fapi" }} ({{ .fapi_profile }}){{ end }}`,
+		Build: func(alias, description string, combination VariantCombination) (plan *PlanMetadata, err error) {
+			if ca == nil {
+				if ca, err = NewCertificateAuthority(fapiCertificateAuthorityName); err != nil {
+					return nil, err
+				}
+			}
+
+			return NewFAPI1AdvancedPlan(alias, description, issuer, "", combination.PlanVariant(), ca, publish)
+		},
+	}
+}
+
+func NewFAPI1AdvancedPlanAll(issuer string, publish Publish) (plans []*PlanMetadata, err error) {
+	return NewFAPI1AdvancedVariantMatrix(issuer, publish).Plans()
+}
+
+// NewFAPI2SecurityProfileVariantMatrix returns the matrix of FAPI 2.0 Security Profile Final plans over the sender
+// constraining methods, client authentication types, and OpenID Connect or plain OAuth for the plain_fapi profile.
+// The plans share a certificate authority which is generated when the first plan using mtls is built.
+func NewFAPI2SecurityProfileVariantMatrix(issuer string, publish Publish) *VariantMatrix {
+	return newFAPI2VariantMatrix(issuer, publish, false)
+}
+
+// NewFAPI2MessageSigningVariantMatrix returns the matrix of FAPI 2.0 Message Signing Final plans like
+// NewFAPI2SecurityProfileVariantMatrix with signed request objects and both plain and JARM responses.
+func NewFAPI2MessageSigningVariantMatrix(issuer string, publish Publish) *VariantMatrix {
+	return newFAPI2VariantMatrix(issuer, publish, true)
+}
+
+func newFAPI2VariantMatrix(issuer string, publish Publish, signing bool) *VariantMatrix {
+	var ca *CertificateAuthority
+
+	matrix := &VariantMatrix{
+		Dimensions: []VariantDimension{
+			{Name: "fapi_profile", Values: fapiProfiles},
+			{Name: "sender_constrain", Values: fapi2SenderConstrains},
+			{Name: "client_auth_type", Values: fapiClientAuthTypes},
+			{Name: "openid", Values: fapi2OpenID},
+		},
+		Alias:       `fapi2-sp-{{ .sender_constrain }}-{{ replace .client_auth_type "_" "-" }}-{{ replace .openid "_" "-" }}{{ if ne .fapi_profile "plain_fapi" }}-{{ replace .fapi_profile "_" "-" }}{{ end }}`,
+		Description: `FAPI2 Security Profile: {{ if eq .sender_constrain "dpop" }}DPoP{{ else }}mTLS{{ end }} {{ clientAuthTypeDescription .client_auth_type }}{{ if eq .openid "plain_oauth" }} OAuth{{ else }} OpenID{{ end }}{{ if ne .fapi_profile "plain_fapi" }} ({{ .fapi_profile }}){{ end }}`,
+	}
+
+	build := NewFAPI2SecurityProfilePlan
+
+	if signing {
+		matrix.Dimensions = append(matrix.Dimensions,
+			VariantDimension{Name: "fapi_request_method", Values: []string{"signed_non_repudiation"}},
+			VariantDimension{Name: "fapi_response_mode", Values: fapiResponseModes},
+		)
+
+		matrix.Alias = `fapi2-ms-{{ .sender_constrain }}-{{ replace .client_auth_type "_" "-" }}-{{ replace .openid "_" "-" }}-{{ replace .fapi_response_mode "_" "-" }}{{ if ne .fapi_profile "plain_fapi" }}-{{ replace .fapi_profile "_" "-" }}{{ end }}`
+		matrix.Description = strings.Replace(matrix.Description, "Security Profile", "Message Signing", 1) + `{{ if eq .fapi_response_mode "jarm" }} JARM{{ end }}`
+
+		build = NewFAPI2MessageSigningPlan
+	}
+
+	matrix.Build = func(alias, description string, combination VariantCombination) (plan *PlanMetadata, err error) {
+		if ca == nil && (combination["client_auth_type"] == "mtls" || combination["sender_constrain"] == "mtls") {
+			if ca, err = NewCertificateAuthority(fapiCertificateAuthorityName); err != nil {
+				return nil, err
+			}
+		}
+
+		return build(alias, description, issuer, "", combination.PlanVariant(), ca, publish)
+	}
+
+	return matrix
+}
+
+// NewFAPI2SecurityProfilePlanAll builds every plan of the NewFAPI2SecurityProfileVariantMatrix.
+func NewFAPI2SecurityProfilePlanAll(issuer string, publish Publish) (plans []*PlanMetadata, err error) {
+	return NewFAPI2SecurityProfileVariantMatrix(issuer, publish).Plans()
+}
+
+// NewFAPI2MessageSigningPlanAll builds every plan of the NewFAPI2MessageSigningVariantMatrix.
+func NewFAPI2MessageSigningPlanAll(issuer string, publish Publish) (plans []*PlanMetadata, err error) {
+	return NewFAPI2MessageSigningVariantMatrix(issuer, publish).Plans()
+}
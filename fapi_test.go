@@ -0,0 +1,180 @@
+package oidcc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewFAPI1AdvancedPlanAll(t *testing.T) {
+	root, _ := url.Parse("https://suite.example.com")
+
+	plans, err := NewFAPI1AdvancedPlanAll("https://idp.example.com", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plans) != 8 {
+		t.Fatalf("expected 8 plans but got %d", len(plans))
+	}
+
+	if cas := ClientCertificateAuthorities(plans...); len(cas) != 1 {
+		t.Errorf("expected the plans to share one certificate authority but got %d", len(cas))
+	}
+
+	aliases := map[string]bool{}
+
+	for _, plan := range plans {
+		alias := plan.Config.Alias
+
+		if aliases[alias] {
+			t.Errorf("%s: duplicate alias", alias)
+		}
+
+		aliases[alias] = true
+
+		if plan.Name != "fapi1-advanced-final-test-plan" || !plan.IsFAPI() || plan.Variant.FAPIProfile != "plain_fapi" {
+			t.Errorf("%s: unexpected plan %s %+v", alias, plan.Name, plan.Variant)
+		}
+
+		if plan.Config.Resource == nil || plan.Config.Resource.ResourceURL != "https://idp.example.com/api/oidc/userinfo" {
+			t.Errorf("%s: unexpected resource %+v", alias, plan.Config.Resource)
+		}
+
+		if plan.Config.MTLS == nil || plan.Config.MTLS2 == nil || plan.Config.MTLS.SelfSigned() {
+			t.Errorf("%s: expected issued client certificates", alias)
+		}
+
+		for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2} {
+			if client.Scope != "openid" || client.ClientSecret != "" || client.JWKS == nil || client.JWKS.Keys[0].Algorithm != "PS256" {
+				t.Errorf("%s: unexpected plan client %s", alias, client.ClientID)
+			}
+		}
+
+		clients, err := plan.GetClientsWithOptions(root, ClientOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(clients) != 2 {
+			t.Fatalf("%s: expected 2 clients but got %d", alias, len(clients))
+		}
+
+		if err = ValidateClients(clients); err != nil {
+			t.Errorf("%s: %v", alias, err)
+		}
+
+		jarm, par := plan.Variant.FAPIResponseMode == "jarm", plan.Variant.FAPIAuthRequestMethod == "pushed"
+
+		expectedResponseType := "code id_token"
+		if jarm {
+			expectedResponseType = "code"
+		}
+
+		expectedAuthMethod := "private_key_jwt"
+		if plan.Variant.ClientAuthType == "mtls" {
+			expectedAuthMethod = "tls_client_auth"
+		}
+
+		for _, client := range clients {
+			if client.TokenEndpointAuthMethod != expectedAuthMethod || strings.Join(client.ResponseTypes, ",") != expectedResponseType {
+				t.Errorf("%s: unexpected auth method %s or response types %v", alias, client.TokenEndpointAuthMethod, client.ResponseTypes)
+			}
+
+			if client.RequestObjectSigningAlg != "PS256" || client.IDTokenSignedResponseAlg != "PS256" {
+				t.Errorf("%s: unexpected signing algs %s %s", alias, client.RequestObjectSigningAlg, client.IDTokenSignedResponseAlg)
+			}
+
+			if (client.AuthorizationSignedResponseAlg == "PS256") != jarm {
+				t.Errorf("%s: unexpected authorization signed response alg '%s'", alias, client.AuthorizationSignedResponseAlg)
+			}
+
+			if client.RequirePushedAuthorizationRequests != par || client.RequirePKCE != par {
+				t.Errorf("%s: unexpected pushed authorization requests %t or pkce %t", alias, client.RequirePushedAuthorizationRequests, client.RequirePKCE)
+			}
+
+			if !client.TLSClientCertificateBoundTokens || client.DPoPBoundAccessTokens {
+				t.Errorf("%s: expected certificate bound access tokens", alias)
+			}
+		}
+	}
+
+	if _, err = NewFAPI1AdvancedPlan("alias", "description", "https://idp.example.com", "", &PlanVariant{ClientAuthType: "private_key_jwt"}, nil, NoPublish); err == nil {
+		t.Errorf("expected an error building a plan without a certificate authority")
+	}
+
+	if !aliases["fapi1-private-key-jwt-jarm-pushed"] || !aliases["fapi1-mtls-plain-response-by-value"] {
+		t.Errorf("unexpected aliases %v", aliases)
+	}
+}
+
+func TestNewFAPI2PlanAll(t *testing.T) {
+	root, _ := url.Parse("https://suite.example.com")
+
+	securityProfile, err := NewFAPI2SecurityProfilePlanAll("https://idp.example.com", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messageSigning, err := NewFAPI2MessageSigningPlanAll("https://idp.example.com", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(securityProfile) != 8 || len(messageSigning) != 16 {
+		t.Fatalf("expected 8 and 16 plans but got %d and %d", len(securityProfile), len(messageSigning))
+	}
+
+	for _, plan := range append(securityProfile, messageSigning...) {
+		alias, variant := plan.Config.Alias, plan.Variant
+
+		mtls := variant.ClientAuthType == "mtls" || variant.SenderConstrain == "mtls"
+
+		if (plan.Config.MTLS != nil) != mtls {
+			t.Errorf("%s: expected client certificates only when using mtls", alias)
+		}
+
+		if plan.Name == "fapi2-message-signing-final-test-plan" && variant.FAPIRequestMethod != "signed_non_repudiation" {
+			t.Errorf("%s: expected signed request objects", alias)
+		}
+
+		if scope := plan.Config.Client.Scope; (scope == "openid") != (variant.OpenID == "openid_connect") {
+			t.Errorf("%s: unexpected scope '%s'", alias, scope)
+		}
+
+		clients, err := plan.GetClientsWithOptions(root, ClientOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = ValidateClients(clients); err != nil {
+			t.Errorf("%s: %v", alias, err)
+		}
+
+		for _, client := range clients {
+			if strings.Join(client.ResponseTypes, ",") != "code" || !client.RequirePushedAuthorizationRequests || !client.RequirePKCE || client.PKCEChallengeMethod != "S256" {
+				t.Errorf("%s: expected the code response type with PAR and PKCE %+v", alias, client)
+			}
+
+			if client.DPoPBoundAccessTokens != (variant.SenderConstrain == "dpop") || client.TLSClientCertificateBoundTokens != (variant.SenderConstrain == "mtls") {
+				t.Errorf("%s: unexpected sender constraining dpop %t mtls %t", alias, client.DPoPBoundAccessTokens, client.TLSClientCertificateBoundTokens)
+			}
+
+			if (client.AuthorizationSignedResponseAlg != "") != (variant.FAPIResponseMode == "jarm") {
+				t.Errorf("%s: unexpected authorization signed response alg '%s'", alias, client.AuthorizationSignedResponseAlg)
+			}
+		}
+	}
+
+	if _, err = NewFAPI2SecurityProfilePlan("alias", "description", "https://idp.example.com", "", &PlanVariant{ClientAuthType: "mtls"}, nil, NoPublish); err == nil {
+		t.Errorf("expected an error building an mtls plan without a certificate authority")
+	}
+
+	if securityProfile[0].Config.Alias != "fapi2-sp-dpop-private-key-jwt-openid-connect" || securityProfile[0].Config.Description != "FAPI2 Security Profile: DPoP Private Key JWT OpenID" {
+		t.Errorf("unexpected plan %s %s", securityProfile[0].Config.Alias, securityProfile[0].Config.Description)
+	}
+
+	if last := messageSigning[len(messageSigning)-1]; last.Config.Alias != "fapi2-ms-mtls-mtls-plain-oauth-jarm" || last.Config.Description != "FAPI2 Message Signing: mTLS mTLS OAuth JARM" {
+		t.Errorf("unexpected plan %s %s", last.Config.Alias, last.Config.Description)
+	}
+}
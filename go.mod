@@ -2,4 +2,7 @@ module github.com/james-d-elliott/go-oidcc
 
 go 1.22
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require (
+	golang.org/x/crypto v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
+)
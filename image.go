@@ -0,0 +1,222 @@
+package oidcc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GetTestImages returns the log entries of a test instance which either expect an image to be uploaded or contain an
+// uploaded image.
+func (c *APIClient) GetTestImages(ctx context.Context, testID string) (entries []LogEntry, err error) {
+	resp, err := c.DoContext(ctx, http.MethodGet, nil, nil, "log", testID, "images")
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("request for the images of test '%s' failed with status %d and data: %s", testID, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("error decoding the images of test '%s': %w", testID, err)
+	}
+
+	return entries, nil
+}
+
+// PendingImages returns the entries which are placeholders for an image which has not been uploaded yet.
+func PendingImages(entries []LogEntry) (pending []LogEntry) {
+	for _, entry := range entries {
+		if entry.Upload == "" {
+			continue
+		}
+
+		if _, ok := entry.Extra["img"]; ok {
+			continue
+		}
+
+		pending = append(pending, entry)
+	}
+
+	return pending
+}
+
+// UploadTestImage uploads an image to a placeholder of a test instance. If the placeholder is empty the image is added
+// to the log of the test instance with the description instead.
+func (c *APIClient) UploadTestImage(ctx context.Context, testID, placeholder, description string, data []byte) (err error) {
+	path := []string{"log", testID, "images"}
+
+	var query url.Values
+
+	if placeholder != "" {
+		path = append(path, placeholder)
+	} else if description != "" {
+		query = url.Values{"description": []string{description}}
+	}
+
+	body := strings.NewReader(imageDataURL(data))
+
+	resp, err := c.doContext(ctx, http.MethodPost, "text/plain", body, query, path...)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+
+		return fmt.Errorf("request to upload an image to test '%s' failed with status %d and data: %s", testID, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return nil
+}
+
+func imageDataURL(data []byte) string {
+	return "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+type ImageUploadOptions struct {
+	// Files maps a test module name to the image file uploaded to every pending placeholder of that module.
+	Files map[string]string
+
+	// Placeholder uploads a generated PNG with the test metadata embedded to every pending placeholder which does not
+	// have a file.
+	Placeholder bool
+}
+
+// UploadReviewImages uploads an image to every pending image placeholder of a test instance and returns the
+// placeholders which were uploaded.
+func (c *APIClient) UploadReviewImages(ctx context.Context, testID string, opts ImageUploadOptions) (uploaded []string, err error) {
+	var (
+		info    *TestInfo
+		entries []LogEntry
+	)
+
+	if info, err = c.GetTestInfo(ctx, testID); err != nil {
+		return nil, err
+	}
+
+	if entries, err = c.GetTestImages(ctx, testID); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range PendingImages(entries) {
+		var data []byte
+
+		switch file, ok := opts.Files[info.TestName]; {
+		case ok:
+			if data, err = os.ReadFile(filepath.Clean(file)); err != nil {
+				return uploaded, fmt.Errorf("error reading image for module '%s': %w", info.TestName, err)
+			}
+		case opts.Placeholder:
+			metadata := map[string]string{
+				"Title":         info.TestName,
+				"Description":   entry.Message,
+				"Test ID":       testID,
+				"Plan ID":       info.PlanID,
+				"Alias":         info.Alias,
+				"Placeholder":   entry.Upload,
+				"Creation Time": time.Now().UTC().Format(time.RFC3339),
+			}
+
+			if data, err = NewPlaceholderPNG(metadata); err != nil {
+				return uploaded, err
+			}
+		default:
+			continue
+		}
+
+		if err = c.UploadTestImage(ctx, testID, entry.Upload, "", data); err != nil {
+			return uploaded, err
+		}
+
+		uploaded = append(uploaded, entry.Upload)
+	}
+
+	return uploaded, nil
+}
+
+// NewPlaceholderPNG generates a PNG with the metadata embedded as tEXt chunks.
+func NewPlaceholderPNG(metadata map[string]string) (data []byte, err error) {
+	const width, height, border = 640, 360, 8
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+
+			if x < border || y < border || x >= width-border || y >= height-border {
+				c = color.RGBA{R: 0x8e, G: 0x44, B: 0xad, A: 0xff}
+			}
+
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err = png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("error encoding placeholder image: %w", err)
+	}
+
+	return pngWithText(buf.Bytes(), metadata)
+}
+
+// pngWithText inserts a tEXt chunk for each of the metadata keys directly after the IHDR chunk of the PNG.
+func pngWithText(data []byte, metadata map[string]string) ([]byte, error) {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4
+
+	if len(data) < ihdrEnd || string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("error adding metadata to image: data is not a PNG")
+	}
+
+	keys := make([]string, 0, len(metadata))
+
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	out := bytes.NewBuffer(append([]byte(nil), data[:ihdrEnd]...))
+
+	for _, key := range keys {
+		if metadata[key] == "" {
+			continue
+		}
+
+		chunk := append([]byte("tEXt"+key+"\x00"), metadata[key]...)
+
+		_ = binary.Write(out, binary.BigEndian, uint32(len(chunk)-4))
+
+		out.Write(chunk)
+
+		_ = binary.Write(out, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+	}
+
+	out.Write(data[ihdrEnd:])
+
+	return out.Bytes(), nil
+}
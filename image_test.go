@@ -0,0 +1,106 @@
+package oidcc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewPlaceholderPNG(t *testing.T) {
+	data, err := NewPlaceholderPNG(map[string]string{"Title": "oidcc-prompt-login", "Test ID": "abc", "Empty": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a valid png: %v", err)
+	}
+
+	if img.Bounds().Dx() != 640 {
+		t.Errorf("unexpected image bounds %v", img.Bounds())
+	}
+
+	for _, text := range []string{"tEXtTest ID\x00abc", "tEXtTitle\x00oidcc-prompt-login"} {
+		if !bytes.Contains(data, []byte(text)) {
+			t.Errorf("expected the png to contain the %q text chunk", text)
+		}
+	}
+
+	if bytes.Contains(data, []byte("tEXtEmpty")) {
+		t.Errorf("expected empty metadata to be omitted")
+	}
+}
+
+func TestUploadReviewImages(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	suite.setInfo(TestInfo{TestID: "test-1", TestName: "oidcc-prompt-login", Status: TestStatusWaiting})
+	suite.setInfo(TestInfo{TestID: "test-2", TestName: "oidcc-ensure-registered-redirect-uri", Status: TestStatusWaiting})
+	suite.setInfo(TestInfo{TestID: "test-3", TestName: "oidcc-max-age-1", Status: TestStatusWaiting})
+
+	for _, id := range []string{"test-1", "test-2", "test-3"} {
+		suite.log(id,
+			LogEntry{Time: 1, Message: "Please upload a screenshot", Upload: id + "-placeholder"},
+			LogEntry{Time: 2, Message: "Already uploaded", Upload: id + "-done", Extra: map[string]json.RawMessage{"img": json.RawMessage(`"data:image/png;base64,"`)}},
+		)
+	}
+
+	file := filepath.Join(t.TempDir(), "error.png")
+
+	data, err := NewPlaceholderPNG(map[string]string{"Title": "user supplied"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := suite.client()
+	opts := ImageUploadOptions{Files: map[string]string{"oidcc-ensure-registered-redirect-uri": file}, Placeholder: true}
+
+	for _, id := range []string{"test-1", "test-2"} {
+		uploaded, err := client.UploadReviewImages(context.Background(), id, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(uploaded) != 1 || uploaded[0] != id+"-placeholder" {
+			t.Errorf("unexpected uploads %v", uploaded)
+		}
+	}
+
+	entries, err := client.GetTestImages(context.Background(), "test-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(PendingImages(entries)) != 0 {
+		t.Errorf("expected no pending images")
+	}
+
+	img := entries[0].String("img")
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(img, "data:image/png;base64,"))
+	if err != nil || !bytes.Contains(decoded, []byte("tEXtTest ID\x00test-1")) {
+		t.Errorf("expected the generated placeholder to be uploaded: %v", err)
+	}
+
+	entries, _ = client.GetTestImages(context.Background(), "test-2")
+
+	if !strings.Contains(entries[0].String("img"), base64.StdEncoding.EncodeToString(data)) {
+		t.Errorf("expected the user supplied file to be uploaded")
+	}
+
+	uploaded, err := client.UploadReviewImages(context.Background(), "test-3", ImageUploadOptions{})
+	if err != nil || len(uploaded) != 0 {
+		t.Errorf("expected nothing to be uploaded without a file or placeholder but got %v: %v", uploaded, err)
+	}
+}
@@ -0,0 +1,163 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type TestStatus string
+
+const (
+	TestStatusCreated     TestStatus = "CREATED"
+	TestStatusConfigured  TestStatus = "CONFIGURED"
+	TestStatusWaiting     TestStatus = "WAITING"
+	TestStatusRunning     TestStatus = "RUNNING"
+	TestStatusFinished    TestStatus = "FINISHED"
+	TestStatusInterrupted TestStatus = "INTERRUPTED"
+)
+
+// Done returns true if the test instance will not log any further entries.
+func (s TestStatus) Done() bool {
+	return s == TestStatusFinished || s == TestStatusInterrupted
+}
+
+type TestResult string
+
+const (
+	TestResultPassed  TestResult = "PASSED"
+	TestResultFailed  TestResult = "FAILED"
+	TestResultWarning TestResult = "WARNING"
+	TestResultReview  TestResult = "REVIEW"
+	TestResultSkipped TestResult = "SKIPPED"
+	TestResultUnknown TestResult = "UNKNOWN"
+)
+
+// TestResultFromLog returns the test result implied by the worst result of the log entries of a test.
+func TestResultFromLog(result LogResult) TestResult {
+	switch result {
+	case LogResultFailure, LogResultInterrupted:
+		return TestResultFailed
+	case LogResultWarning:
+		return TestResultWarning
+	case LogResultReview:
+		return TestResultReview
+	case LogResultSkipped:
+		return TestResultSkipped
+	case LogResultSuccess, LogResultInfo:
+		return TestResultPassed
+	default:
+		return TestResultUnknown
+	}
+}
+
+type TestInfo struct {
+	ID          string       `json:"_id,omitempty"`
+	TestID      string       `json:"testId"`
+	TestName    string       `json:"testName"`
+	PlanID      string       `json:"planId,omitempty"`
+	Alias       string       `json:"alias,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Variant     *PlanVariant `json:"variant,omitempty"`
+	Status      TestStatus   `json:"status"`
+	Result      TestResult   `json:"result,omitempty"`
+	Version     string       `json:"version,omitempty"`
+}
+
+func (c *APIClient) GetTestInfo(ctx context.Context, testID string) (info *TestInfo, err error) {
+	resp, err := c.DoContext(ctx, http.MethodGet, nil, nil, "info", testID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("request for the info of test '%s' failed with status %d and data: %s", testID, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	info = &TestInfo{}
+
+	if err = json.NewDecoder(resp.Body).Decode(info); err != nil {
+		return nil, fmt.Errorf("error decoding the info of test '%s': %w", testID, err)
+	}
+
+	return info, nil
+}
+
+type TestCreateResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// CreateTestInstance creates and starts a test instance of a module in a plan.
+func (c *APIClient) CreateTestInstance(ctx context.Context, planID, module string, variant *PlanVariant) (response *TestCreateResponse, err error) {
+	query := url.Values{}
+
+	query.Set("plan", planID)
+	query.Set("test", module)
+
+	if variant != nil {
+		var data []byte
+
+		if data, err = json.Marshal(variant); err != nil {
+			return nil, err
+		}
+
+		if string(data) != "{}" {
+			query.Set("variant", string(data))
+		}
+	}
+
+	resp, err := c.DoContext(ctx, http.MethodPost, nil, query, "runner")
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("request to create test '%s' in plan '%s' failed with status %d and data: %s", module, planID, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	response = &TestCreateResponse{}
+
+	if err = json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("error decoding the created test '%s': %w", module, err)
+	}
+
+	return response, nil
+}
+
+// WaitForTest polls the info of a test instance until it has finished or been interrupted.
+func (c *APIClient) WaitForTest(ctx context.Context, testID string, interval time.Duration) (info *TestInfo, err error) {
+	if interval <= 0 {
+		interval = DefaultTailInterval
+	}
+
+	for {
+		if info, err = c.GetTestInfo(ctx, testID); err != nil {
+			return nil, err
+		}
+
+		if info.Status.Done() {
+			return info, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return info, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package oidcc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// DefaultClientKeyAlg is the algorithm of the keys generated for private_key_jwt clients when none is specified.
+const DefaultClientKeyAlg = "ES256"
+
+// JSONWebKey is a JSON Web Key as described in RFC 7517 which holds an RSA or EC key. The private members are empty
+// for a public key.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	D  string `json:"d,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+}
+
+// JSONWebKeySet is a JSON Web Key Set as described in RFC 7517.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Public returns the set with the private members removed from every key.
+func (s JSONWebKeySet) Public() JSONWebKeySet {
+	keys := make([]JSONWebKey, len(s.Keys))
+
+	for i, key := range s.Keys {
+		keys[i] = key.Public()
+	}
+
+	return JSONWebKeySet{Keys: keys}
+}
+
+// FetchJSONWebKeySet fetches and parses the JSON Web Key Set at the URI. If the client is nil the http.DefaultClient is
+// used.
+func FetchJSONWebKeySet(ctx context.Context, client *http.Client, uri string) (set *JSONWebKeySet, err error) {
+	var data []byte
+
+	if data, err = fetchJSON(ctx, client, uri, "jwks"); err != nil {
+		return nil, err
+	}
+
+	set = &JSONWebKeySet{}
+
+	if err = json.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("error decoding jwks: %w", err)
+	}
+
+	return set, nil
+}
+
+// GenerateJSONWebKey generates a signing key for the algorithm. The ES256, ES384, and ES512 algorithms generate an EC
+// key on the matching curve, and the RS and PS algorithms generate a 2048 bit RSA key. The key ID is the RFC 7638
+// thumbprint of the key.
+func GenerateJSONWebKey(alg string) (key JSONWebKey, err error) {
+	var signer crypto.Signer
+
+	switch alg {
+	case "ES256":
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		signer, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "ES512":
+		signer, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return key, fmt.Errorf("error generating key: unsupported algorithm '%s'", alg)
+	}
+
+	if err != nil {
+		return key, fmt.Errorf("error generating key: %w", err)
+	}
+
+	return NewJSONWebKey(signer, alg)
+}
+
+// NewJSONWebKey returns the JSON Web Key for an RSA or EC private key used for signing with the algorithm.
+func NewJSONWebKey(signer crypto.Signer, alg string) (key JSONWebKey, err error) {
+	key = JSONWebKey{Use: "sig", Algorithm: alg}
+
+	switch k := signer.(type) {
+	case *ecdsa.PrivateKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+
+		key.KeyType, key.Curve = "EC", k.Curve.Params().Name
+		key.X, key.Y, key.D = jwkInt(k.X, size), jwkInt(k.Y, size), jwkInt(k.D, size)
+	case *rsa.PrivateKey:
+		if len(k.Primes) != 2 {
+			return key, fmt.Errorf("error encoding key: multi-prime rsa keys are not supported")
+		}
+
+		k.Precompute()
+
+		key.KeyType = "RSA"
+		key.N, key.E, key.D = jwkInt(k.N, 0), jwkInt(big.NewInt(int64(k.E)), 0), jwkInt(k.D, 0)
+		key.P, key.Q = jwkInt(k.Primes[0], 0), jwkInt(k.Primes[1], 0)
+		key.DP, key.DQ, key.QI = jwkInt(k.Precomputed.Dp, 0), jwkInt(k.Precomputed.Dq, 0), jwkInt(k.Precomputed.Qinv, 0)
+	default:
+		return key, fmt.Errorf("error encoding key: unsupported key type %T", signer)
+	}
+
+	key.KeyID = key.Thumbprint()
+
+	return key, nil
+}
+
+func jwkInt(i *big.Int, size int) string {
+	data := i.Bytes()
+
+	if len(data) < size {
+		data = append(make([]byte, size-len(data)), data...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Public returns the key with the private members removed.
+func (k JSONWebKey) Public() JSONWebKey {
+	k.D, k.P, k.Q, k.DP, k.DQ, k.QI = "", "", "", "", "", ""
+
+	return k
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of the key.
+func (k JSONWebKey) Thumbprint() string {
+	var members string
+
+	switch k.KeyType {
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Curve, k.X, k.Y)
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	default:
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(members))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// PublicKey returns the public key of the JSON Web Key.
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	decode := func(name, value string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("error decoding key '%s': invalid '%s' member", k.KeyID, name)
+		}
+
+		return new(big.Int).SetBytes(data), nil
+	}
+
+	switch k.KeyType {
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("error decoding key '%s': unsupported curve '%s'", k.KeyID, k.Curve)
+		}
+
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decode("y", k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "RSA":
+		n, err := decode("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decode("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("error decoding key '%s': invalid 'e' member", k.KeyID)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	default:
+		return nil, fmt.Errorf("error decoding key '%s': unsupported key type '%s'", k.KeyID, k.KeyType)
+	}
+}
+
+// PublicKeyPEM returns the PEM encoded PKIX public key of the JSON Web Key.
+func (k JSONWebKey) PublicKeyPEM() (string, error) {
+	public, err := k.PublicKey()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", fmt.Errorf("error encoding key '%s': %w", k.KeyID, err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: data})), nil
+}
+
+// GenerateKey generates a signing key for the algorithm and sets it as the only key of the client JWKS. If the
+// algorithm is empty the DefaultClientKeyAlg is used.
+func (c *PlanClient) GenerateKey(alg string) (err error) {
+	if alg == "" {
+		alg = DefaultClientKeyAlg
+	}
+
+	var key JSONWebKey
+
+	if key, err = GenerateJSONWebKey(alg); err != nil {
+		return fmt.Errorf("client '%s': %w", c.ClientID, err)
+	}
+
+	c.JWKS = &JSONWebKeySet{Keys: []JSONWebKey{key}}
+
+	return nil
+}
+
+// NewJWKSHandler returns a handler which serves the public JWKS of every client of the plans which has keys at
+// /{client_id}/jwks.json, i.e. the path of the jwks_uri which GetClientsWithOptions uses when the JWKSURI option is
+// set.
+func NewJWKSHandler(plans ...*PlanMetadata) http.Handler {
+	sets := map[string][]byte{}
+
+	for _, plan := range plans {
+		if plan == nil || plan.Config == nil {
+			continue
+		}
+
+		for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2, plan.Config.ClientSecretPost} {
+			if client != nil && client.JWKS != nil {
+				sets[client.ClientID], _ = json.Marshal(client.JWKS.Public())
+			}
+		}
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{client_id}/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		data, ok := sets[r.PathValue("client_id")]
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+
+		_, _ = w.Write(data)
+	})
+
+	return mux
+}
+
+// clientJWKSURI returns the jwks_uri of a client served below the base URL by a handler from NewJWKSHandler.
+func clientJWKSURI(base, clientID string) string {
+	return strings.TrimSuffix(base, "/") + "/" + clientID + "/jwks.json"
+}
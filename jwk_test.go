@@ -0,0 +1,129 @@
+package oidcc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestJSONWebKeyThumbprint(t *testing.T) {
+	// The example key from RFC 7638 section 3.1.
+	key := JSONWebKey{
+		KeyType: "RSA",
+		N:       "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:       "AQAB",
+	}
+
+	if thumbprint := key.Thumbprint(); thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("unexpected thumbprint '%s'", thumbprint)
+	}
+}
+
+func TestNewJSONWebKey(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		alg    string
+		signer crypto.Signer
+		kty    string
+	}{
+		{"ES384", ec, "EC"},
+		{"PS256", rs, "RSA"},
+	} {
+		key, err := NewJSONWebKey(tc.signer, tc.alg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key.KeyType != tc.kty || key.Algorithm != tc.alg || key.Use != "sig" || key.D == "" || key.KeyID != key.Thumbprint() {
+			t.Errorf("%s: unexpected key %+v", tc.alg, key)
+		}
+
+		public := key.Public()
+
+		if public.D != "" || public.P != "" || public.QI != "" || public.KeyID != key.KeyID {
+			t.Errorf("%s: expected the private members to be removed %+v", tc.alg, public)
+		}
+
+		decoded, err := public.PublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !decoded.(interface{ Equal(crypto.PublicKey) bool }).Equal(tc.signer.Public()) {
+			t.Errorf("%s: expected the decoded public key to match the signer", tc.alg)
+		}
+
+		data, err := public.PublicKeyPEM()
+		if err != nil || !strings.HasPrefix(data, "-----BEGIN PUBLIC KEY-----\n") {
+			t.Errorf("%s: unexpected pem %q %v", tc.alg, data, err)
+		}
+	}
+
+	if _, err = GenerateJSONWebKey("HS256"); err == nil {
+		t.Errorf("expected an error generating a symmetric key")
+	}
+}
+
+func TestNewJWKSHandler(t *testing.T) {
+	matrix := NewComprehensiveVariantMatrix("secret", "https://idp.example.com", NoPublish)
+
+	plan, err := matrix.Build("alias", "description", VariantCombination{"client_auth_type": "private_key_jwt", "response_type": "code", "response_mode": "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(NewJWKSHandler(plan))
+
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/" + plan.Config.Client.ClientID + "/jwks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	jwks := JSONWebKeySet{}
+
+	if err = json.Unmarshal(data, &jwks); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK || len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != plan.Config.Client.JWKS.Keys[0].KeyID || jwks.Keys[0].D != "" {
+		t.Errorf("unexpected response %d %s", resp.StatusCode, data)
+	}
+
+	if resp, err = http.Get(server.URL + "/unknown/jwks.json"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected not found for an unknown client")
+	}
+
+	root, _ := url.Parse("https://suite.example.com")
+
+	clients, err := plan.GetClientsWithOptions(root, ClientOptions{JWKSURI: server.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if clients[0].JSONWebKeysURI != server.URL+"/"+plan.Config.Client.ClientID+"/jwks.json" || len(clients[0].JSONWebKeys) != 0 {
+		t.Errorf("unexpected client keys %s %v", clients[0].JSONWebKeysURI, clients[0].JSONWebKeys)
+	}
+}
@@ -0,0 +1,363 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LogResult string
+
+const (
+	LogResultSuccess     LogResult = "SUCCESS"
+	LogResultFailure     LogResult = "FAILURE"
+	LogResultWarning     LogResult = "WARNING"
+	LogResultReview      LogResult = "REVIEW"
+	LogResultSkipped     LogResult = "SKIPPED"
+	LogResultInterrupted LogResult = "INTERRUPTED"
+	LogResultInfo        LogResult = "INFO"
+)
+
+// Severity orders the results from the least to the most severe so the worst of several results can be determined.
+func (r LogResult) Severity() int {
+	switch r {
+	case LogResultInfo:
+		return 1
+	case LogResultSuccess:
+		return 2
+	case LogResultSkipped:
+		return 3
+	case LogResultReview:
+		return 4
+	case LogResultWarning:
+		return 5
+	case LogResultInterrupted:
+		return 6
+	case LogResultFailure:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// Worse returns whichever of the two results is the most severe.
+func (r LogResult) Worse(other LogResult) LogResult {
+	if other.Severity() > r.Severity() {
+		return other
+	}
+
+	return r
+}
+
+// LogEntry is a single entry in the log of a test instance. Any fields which are not explicitly modelled are retained
+// in Extra and are written back out when the entry is marshalled.
+type LogEntry struct {
+	ID           string    `json:"_id,omitempty"`
+	TestID       string    `json:"testId,omitempty"`
+	Source       string    `json:"src,omitempty"`
+	Time         int64     `json:"time,omitempty"`
+	Message      string    `json:"msg,omitempty"`
+	Result       LogResult `json:"result,omitempty"`
+	Requirements []string  `json:"requirements,omitempty"`
+	BlockID      string    `json:"blockId,omitempty"`
+	StartBlock   bool      `json:"startBlock,omitempty"`
+	HTTP         string    `json:"http,omitempty"`
+	Upload       string    `json:"upload,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+type logEntry LogEntry
+
+var logEntryKeys = []string{"_id", "testId", "src", "time", "msg", "result", "requirements", "blockId", "startBlock", "http", "upload"}
+
+func (e *LogEntry) UnmarshalJSON(data []byte) (err error) {
+	var raw map[string]json.RawMessage
+
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	entry := logEntry{}
+
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+
+	for _, key := range logEntryKeys {
+		delete(raw, key)
+	}
+
+	if len(raw) != 0 {
+		entry.Extra = raw
+	}
+
+	*e = LogEntry(entry)
+
+	return nil
+}
+
+func (e LogEntry) MarshalJSON() (data []byte, err error) {
+	if data, err = json.Marshal(logEntry(e)); err != nil {
+		return nil, err
+	}
+
+	if len(e.Extra) == 0 {
+		return data, nil
+	}
+
+	raw := map[string]json.RawMessage{}
+
+	for key, value := range e.Extra {
+		raw[key] = value
+	}
+
+	if err = json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(raw)
+}
+
+func (e LogEntry) Timestamp() time.Time {
+	return time.UnixMilli(e.Time)
+}
+
+// Field decodes the named field which is not explicitly modelled into v and returns false if it is absent.
+func (e LogEntry) Field(key string, v any) (ok bool, err error) {
+	value, ok := e.Extra[key]
+	if !ok {
+		return false, nil
+	}
+
+	if err = json.Unmarshal(value, v); err != nil {
+		return true, fmt.Errorf("error decoding log entry field '%s': %w", key, err)
+	}
+
+	return true, nil
+}
+
+// String returns the named field which is not explicitly modelled as a string. Fields which are not JSON strings are
+// returned as their raw JSON.
+func (e LogEntry) String(key string) string {
+	value, ok := e.Extra[key]
+	if !ok {
+		return ""
+	}
+
+	var s string
+
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+
+	return string(value)
+}
+
+// LogHTTPExchange is the HTTP detail of a log entry with an http value, i.e. a request made by the suite, the response
+// to it, a request the suite received, or the response the suite sent.
+type LogHTTPExchange struct {
+	Direction  string
+	Method     string
+	URI        string
+	Path       string
+	StatusCode int
+	Headers    http.Header
+	Query      url.Values
+	Body       string
+}
+
+// HTTPExchange returns the HTTP detail of the entry or nil if the entry does not describe an HTTP exchange.
+func (e LogEntry) HTTPExchange() *LogHTTPExchange {
+	if e.HTTP == "" {
+		return nil
+	}
+
+	prefix := e.HTTP + "_"
+
+	exchange := &LogHTTPExchange{
+		Direction: e.HTTP,
+		Method:    e.String(prefix + "method"),
+		URI:       e.String(prefix + "uri"),
+		Path:      e.String(prefix + "path"),
+		Headers:   e.httpHeader(prefix + "headers"),
+		Query:     e.multiValue(prefix + "query_string_params"),
+		Body:      e.String(prefix + "body"),
+	}
+
+	if exchange.URI == "" {
+		exchange.URI = e.String(e.HTTP + "_to")
+	}
+
+	if exchange.Body == "" {
+		exchange.Body = e.String(prefix + "body_json")
+	}
+
+	if code := e.String(prefix + "status_code"); code != "" {
+		exchange.StatusCode, _ = strconv.Atoi(code)
+	}
+
+	return exchange
+}
+
+func (e LogEntry) httpHeader(key string) (header http.Header) {
+	values := e.multiValue(key)
+	if values == nil {
+		return nil
+	}
+
+	header = http.Header{}
+
+	for name, v := range values {
+		for _, value := range v {
+			header.Add(name, value)
+		}
+	}
+
+	return header
+}
+
+func (e LogEntry) multiValue(key string) (values map[string][]string) {
+	var raw map[string]any
+
+	if ok, err := e.Field(key, &raw); !ok || err != nil {
+		return nil
+	}
+
+	values = map[string][]string{}
+
+	for name, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[name] = append(values[name], v)
+		case []any:
+			for _, item := range v {
+				values[name] = append(values[name], fmt.Sprint(item))
+			}
+		default:
+			values[name] = append(values[name], fmt.Sprint(v))
+		}
+	}
+
+	return values
+}
+
+// LogBlock is a group of log entries which share a block ID. Entries which are not in a block are grouped into blocks
+// with an empty ID.
+type LogBlock struct {
+	ID      string
+	Message string
+	Entries []LogEntry
+}
+
+func GroupLogEntries(entries []LogEntry) (blocks []LogBlock) {
+	index := map[string]int{}
+
+	for _, entry := range entries {
+		if entry.BlockID == "" {
+			if len(blocks) == 0 || blocks[len(blocks)-1].ID != "" {
+				blocks = append(blocks, LogBlock{})
+			}
+
+			blocks[len(blocks)-1].Entries = append(blocks[len(blocks)-1].Entries, entry)
+
+			continue
+		}
+
+		i, ok := index[entry.BlockID]
+		if !ok {
+			i = len(blocks)
+			index[entry.BlockID] = i
+
+			blocks = append(blocks, LogBlock{ID: entry.BlockID})
+		}
+
+		if entry.StartBlock {
+			blocks[i].Message = entry.Message
+
+			continue
+		}
+
+		blocks[i].Entries = append(blocks[i].Entries, entry)
+	}
+
+	return blocks
+}
+
+// GetTestLog returns the log entries of a test instance which were logged after the since timestamp in milliseconds.
+func (c *APIClient) GetTestLog(ctx context.Context, testID string, since int64) (entries []LogEntry, err error) {
+	query := url.Values{}
+
+	if since != 0 {
+		query.Set("since", strconv.FormatInt(since, 10))
+	}
+
+	resp, err := c.DoContext(ctx, http.MethodGet, nil, query, "log", testID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+
+		return nil, fmt.Errorf("request for the log of test '%s' failed with status %d and data: %s", testID, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("error decoding the log of test '%s': %w", testID, err)
+	}
+
+	return entries, nil
+}
+
+// GetTestLogAll pages through the log of a test instance by timestamp until no further entries are returned. Like
+// FollowTestLog each page is fetched from one millisecond before the latest entry and entries already returned are
+// skipped by ID, so entries logged in the same millisecond after the previous page aren't missed.
+func (c *APIClient) GetTestLogAll(ctx context.Context, testID string) (entries []LogEntry, err error) {
+	seen := map[string]bool{}
+
+	var latest int64
+
+	for {
+		var page []LogEntry
+
+		if page, err = c.GetTestLog(ctx, testID, max(latest-1, 0)); err != nil {
+			return nil, err
+		}
+
+		added, previous := 0, latest
+
+		for _, entry := range page {
+			switch {
+			case entry.ID == "":
+				if previous != 0 && entry.Time <= previous {
+					continue
+				}
+			case seen[entry.ID]:
+				continue
+			default:
+				seen[entry.ID] = true
+			}
+
+			entries = append(entries, entry)
+
+			added++
+
+			if entry.Time > latest {
+				latest = entry.Time
+			}
+		}
+
+		if added == 0 {
+			return entries, nil
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const testLogEntryJSON = `{
+	"_id": "abc",
+	"testId": "test-1",
+	"src": "CallTokenEndpointAndReturnFullResponse",
+	"time": 1700000000123,
+	"msg": "Token endpoint response",
+	"result": "FAILURE",
+	"requirements": ["OIDCC-3.1.3.3", "RFC6749-5.2"],
+	"blockId": "block-1",
+	"http": "response",
+	"response_status_code": 401,
+	"response_headers": {"content-type": "application/json", "set-cookie": ["a=1", "b=2"]},
+	"response_body": "{\"error\":\"invalid_client\"}",
+	"error": "invalid_client",
+	"custom": {"nested": true}
+}`
+
+func TestLogEntryUnmarshalPreservesUnknownFields(t *testing.T) {
+	entry := LogEntry{}
+
+	if err := json.Unmarshal([]byte(testLogEntryJSON), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.ID != "abc" || entry.Source != "CallTokenEndpointAndReturnFullResponse" || entry.Result != LogResultFailure || len(entry.Requirements) != 2 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if entry.Timestamp().UnixMilli() != 1700000000123 {
+		t.Errorf("unexpected timestamp %v", entry.Timestamp())
+	}
+
+	if entry.String("error") != "invalid_client" || entry.String("custom") != `{"nested": true}` {
+		t.Errorf("unexpected extra fields %v", entry.Extra)
+	}
+
+	if _, ok := entry.Extra["msg"]; ok {
+		t.Errorf("expected modelled fields to be excluded from the extra fields")
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expected, actual map[string]any
+
+	_ = json.Unmarshal([]byte(testLogEntryJSON), &expected)
+	_ = json.Unmarshal(data, &actual)
+
+	if len(expected) != len(actual) {
+		t.Errorf("expected the marshalled entry to retain every field but got %s", data)
+	}
+
+	exchange := entry.HTTPExchange()
+	if exchange == nil {
+		t.Fatal("expected an http exchange")
+	}
+
+	if exchange.StatusCode != http.StatusUnauthorized || exchange.Headers.Get("Content-Type") != "application/json" || len(exchange.Headers.Values("Set-Cookie")) != 2 || exchange.Body != `{"error":"invalid_client"}` {
+		t.Errorf("unexpected http exchange %+v", exchange)
+	}
+}
+
+func TestGroupLogEntries(t *testing.T) {
+	entries := []LogEntry{
+		{Message: "setup"},
+		{BlockID: "a", StartBlock: true, Message: "Make request to authorization endpoint"},
+		{BlockID: "a", Message: "one"},
+		{BlockID: "a", Message: "two"},
+		{Message: "between"},
+		{BlockID: "b", StartBlock: true, Message: "Verify token endpoint response"},
+		{BlockID: "b", Message: "three"},
+	}
+
+	blocks := GroupLogEntries(entries)
+
+	if len(blocks) != 4 {
+		t.Fatalf("expected 4 blocks but got %d", len(blocks))
+	}
+
+	if blocks[1].ID != "a" || blocks[1].Message != "Make request to authorization endpoint" || len(blocks[1].Entries) != 2 {
+		t.Errorf("unexpected block %+v", blocks[1])
+	}
+
+	if blocks[2].ID != "" || len(blocks[2].Entries) != 1 || blocks[2].Entries[0].Message != "between" {
+		t.Errorf("unexpected block %+v", blocks[2])
+	}
+}
+
+func TestGetTestLogAll(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	suite.log("test-1", LogEntry{Time: 1, Message: "one"}, LogEntry{Time: 2, Message: "two"}, LogEntry{Time: 2, Message: "three", Extra: map[string]json.RawMessage{"expected": json.RawMessage(`"x"`)}})
+	suite.log("test-2", LogEntry{Time: 1, Message: "other"})
+
+	entries, err := suite.client().GetTestLogAll(context.Background(), "test-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 || entries[2].Message != "three" || entries[2].String("expected") != "x" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	if _, err = suite.client().GetTestLog(context.Background(), "", 0); err == nil {
+		t.Errorf("expected an error for a missing test")
+	}
+}
+
+func TestGetTestLogAllSameTimestamp(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	suite.log("test-1", LogEntry{Time: 5, Message: "one"})
+
+	requests := 0
+
+	suite.onGetLog = func(testID string) {
+		if requests++; requests == 1 {
+			suite.log(testID, LogEntry{Time: 5, Message: "two"})
+		}
+	}
+
+	entries, err := suite.client().GetTestLogAll(context.Background(), "test-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Message != "one" || entries[1].Message != "two" {
+		t.Errorf("expected the entry with the same timestamp from the next page to be returned once but got %+v", entries)
+	}
+}
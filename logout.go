@@ -0,0 +1,110 @@
+package oidcc
+
+import (
+	"net/url"
+)
+
+const (
+	rpInitiatedLogoutPlanName  = "oidcc-rp-initiated-logout-certification-test-plan"
+	frontChannelLogoutPlanName = "oidcc-frontchannel-rp-initiated-logout-certification-test-plan"
+	backChannelLogoutPlanName  = "oidcc-backchannel-rp-initiated-logout-certification-test-plan"
+	sessionManagementPlanName  = "oidcc-session-management-certification-test-plan"
+)
+
+// logoutProfile is the logout mechanism a logout plan tests in addition to RP-Initiated Logout.
+type logoutProfile struct {
+	frontChannel bool
+	backChannel  bool
+}
+
+var logoutProfiles = map[string]logoutProfile{
+	rpInitiatedLogoutPlanName:  {},
+	frontChannelLogoutPlanName: {frontChannel: true},
+	backChannelLogoutPlanName:  {backChannel: true},
+	sessionManagementPlanName:  {},
+}
+
+// IsLogout reports whether the plan is one of the logout certification profile plans.
+func (p PlanMetadata) IsLogout() bool {
+	_, ok := logoutProfiles[p.Name]
+
+	return ok
+}
+
+// applyLogout registers the suite logout callbacks of a logout plan for the client. Every logout plan uses the
+// post_logout_redirect callback, and the front-channel and back-channel plans also register the matching logout URI
+// with the session ID required.
+func (p PlanMetadata) applyLogout(client *Client, root *url.URL) {
+	profile, ok := logoutProfiles[p.Name]
+	if !ok {
+		return
+	}
+
+	base := root.JoinPath("test", "a", p.Config.Alias)
+
+	client.PostLogoutRedirectURIs = []string{base.JoinPath("post_logout_redirect").String()}
+
+	if profile.frontChannel {
+		client.FrontChannelLogoutURI = base.JoinPath("frontchannel_logout").String()
+		client.FrontChannelLogoutSessionRequired = true
+	}
+
+	if profile.backChannel {
+		client.BackChannelLogoutURI = base.JoinPath("backchannel_logout").String()
+		client.BackChannelLogoutSessionRequired = true
+	}
+}
+
+func newCertificationProfileLogoutDiscoveryPlan(name, alias, description, secret, issuer string, publish Publish) (plan *PlanMetadata, err error) {
+	client := &PlanClient{
+		ClientID:     "conformance-" + alias + "-1",
+		ClientSecret: secret,
+	}
+
+	variant := &PlanVariant{
+		ServerMetadata:     "discovery",
+		ClientRegistration: "static_client",
+	}
+
+	return NewPlanDiscovery(name, variant, publish, alias, description, issuer, client, nil, nil)
+}
+
+func NewCertificationProfileRPInitiatedLogoutDiscoveryPlan(alias, description, secret, issuer string, publish Publish) (plan *PlanMetadata, err error) {
+	return newCertificationProfileLogoutDiscoveryPlan(rpInitiatedLogoutPlanName, alias, description, secret, issuer, publish)
+}
+
+func NewCertificationProfileFrontChannelLogoutDiscoveryPlan(alias, description, secret, issuer string, publish Publish) (plan *PlanMetadata, err error) {
+	return newCertificationProfileLogoutDiscoveryPlan(frontChannelLogoutPlanName, alias, description, secret, issuer, publish)
+}
+
+func NewCertificationProfileBackChannelLogoutDiscoveryPlan(alias, description, secret, issuer string, publish Publish) (plan *PlanMetadata, err error) {
+	return newCertificationProfileLogoutDiscoveryPlan(backChannelLogoutPlanName, alias, description, secret, issuer, publish)
+}
+
+func NewCertificationProfileSessionManagementDiscoveryPlan(alias, description, secret, issuer string, publish Publish) (plan *PlanMetadata, err error) {
+	return newCertificationProfileLogoutDiscoveryPlan(sessionManagementPlanName, alias, description, secret, issuer, publish)
+}
+
+// NewLogoutPlansAll builds the RP-Initiated, Front-Channel, Back-Channel, and Session Management logout certification
+// profile plans.
+func NewLogoutPlansAll(issuer, secret string, publish Publish) (plans []*PlanMetadata, err error) {
+	for _, builder := range []struct {
+		build              func(alias, description, secret, issuer string, publish Publish) (*PlanMetadata, error)
+		alias, description string
+	}{
+		{NewCertificationProfileRPInitiatedLogoutDiscoveryPlan, "certification-profile-rp-initiated-logout", "Certification Profile: RP-Initiated Logout"},
+		{NewCertificationProfileFrontChannelLogoutDiscoveryPlan, "certification-profile-frontchannel-logout", "Certification Profile: Front-Channel Logout"},
+		{NewCertificationProfileBackChannelLogoutDiscoveryPlan, "certification-profile-backchannel-logout", "Certification Profile: Back-Channel Logout"},
+		{NewCertificationProfileSessionManagementDiscoveryPlan, "certification-profile-session-management", "Certification Profile: Session Management"},
+	} {
+		var plan *PlanMetadata
+
+		if plan, err = builder.build(builder.alias, builder.description, secret, issuer, publish); err != nil {
+			return nil, err
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
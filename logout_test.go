@@ -0,0 +1,71 @@
+package oidcc
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestNewLogoutPlansAll(t *testing.T) {
+	root := &url.URL{Scheme: "https", Host: "suite.example.com"}
+
+	plans, err := NewLogoutPlansAll("https://idp.example.com", "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name, alias               string
+		frontChannel, backChannel bool
+	}{
+		{"oidcc-rp-initiated-logout-certification-test-plan", "certification-profile-rp-initiated-logout", false, false},
+		{"oidcc-frontchannel-rp-initiated-logout-certification-test-plan", "certification-profile-frontchannel-logout", true, false},
+		{"oidcc-backchannel-rp-initiated-logout-certification-test-plan", "certification-profile-backchannel-logout", false, true},
+		{"oidcc-session-management-certification-test-plan", "certification-profile-session-management", false, false},
+	}
+
+	if len(plans) != len(expected) {
+		t.Fatalf("expected %d plans but got %d", len(expected), len(plans))
+	}
+
+	for i, plan := range plans {
+		e := expected[i]
+
+		if plan.Name != e.name || plan.Config.Alias != e.alias || !plan.IsLogout() {
+			t.Errorf("plan %d: unexpected plan %s %s", i, plan.Name, plan.Config.Alias)
+		}
+
+		clients := plan.GetClients(root)
+
+		if len(clients) != 1 {
+			t.Fatalf("plan %d: expected 1 client but got %d", i, len(clients))
+		}
+
+		client := clients[0]
+		base := "https://suite.example.com/test/a/" + e.alias + "/"
+
+		if len(client.PostLogoutRedirectURIs) != 1 || client.PostLogoutRedirectURIs[0] != base+"post_logout_redirect" {
+			t.Errorf("plan %d: unexpected post logout redirect uris %v", i, client.PostLogoutRedirectURIs)
+		}
+
+		if (client.FrontChannelLogoutURI == base+"frontchannel_logout") != e.frontChannel || client.FrontChannelLogoutSessionRequired != e.frontChannel {
+			t.Errorf("plan %d: unexpected front-channel logout '%s' %t", i, client.FrontChannelLogoutURI, client.FrontChannelLogoutSessionRequired)
+		}
+
+		if (client.BackChannelLogoutURI == base+"backchannel_logout") != e.backChannel || client.BackChannelLogoutSessionRequired != e.backChannel {
+			t.Errorf("plan %d: unexpected back-channel logout '%s' %t", i, client.BackChannelLogoutURI, client.BackChannelLogoutSessionRequired)
+		}
+
+		if err = client.Validate(); err != nil {
+			t.Errorf("plan %d: %v", i, err)
+		}
+	}
+
+	basic, err := NewCertificationProfileBasicDiscoveryPlan("basic", "Basic", "secret", "https://idp.example.com", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client := basic.GetClients(root)[0]; basic.IsLogout() || len(client.PostLogoutRedirectURIs) != 0 {
+		t.Errorf("expected no logout uris for a plan which does not test logout")
+	}
+}
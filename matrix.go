@@ -0,0 +1,254 @@
+package oidcc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+type VariantDimension struct {
+	Name   string
+	Values []string
+}
+
+type VariantCombination map[string]string
+
+// Matches returns true if every key in the rule has the same value in the combination.
+func (c VariantCombination) Matches(rule VariantCombination) bool {
+	for key, value := range rule {
+		if v, ok := c[key]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c VariantCombination) String() string {
+	keys := make([]string, 0, len(c))
+
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+
+	for i, key := range keys {
+		parts[i] = key + "=" + c[key]
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (c VariantCombination) PlanVariant() *PlanVariant {
+	return &PlanVariant{
+		ServerMetadata:        c["server_metadata"],
+		ClientRegistration:    c["client_registration"],
+		ClientAuthType:        c["client_auth_type"],
+		ResponseType:          c["response_type"],
+		ResponseMode:          c["response_mode"],
+		FAPIProfile:           c["fapi_profile"],
+		FAPIResponseMode:      c["fapi_response_mode"],
+		FAPIAuthRequestMethod: c["fapi_auth_request_method"],
+		FAPIClientType:        c["fapi_client_type"],
+		FAPIRequestMethod:     c["fapi_request_method"],
+		SenderConstrain:       c["sender_constrain"],
+		OpenID:                c["openid"],
+	}
+}
+
+// VariantConstraint skips every combination where Allow returns false.
+type VariantConstraint struct {
+	Name  string
+	Allow func(combination VariantCombination) bool
+}
+
+type VariantPlanBuilder func(alias, description string, combination VariantCombination) (plan *PlanMetadata, err error)
+
+// VariantMatrix generates the cartesian product of its dimensions, skips the combinations matched by the Exclude rules
+// or rejected by the Constraints, and appends the Include cases. The Alias and Description are text/template strings
+// executed against each combination.
+type VariantMatrix struct {
+	Dimensions  []VariantDimension
+	Exclude     []VariantCombination
+	Constraints []VariantConstraint
+	Include     []VariantCombination
+	Alias       string
+	Description string
+	Build       VariantPlanBuilder
+}
+
+// Combinations returns every combination of the matrix in dimension order followed by the Include cases.
+func (m *VariantMatrix) Combinations() (combinations []VariantCombination) {
+	seen := map[string]bool{}
+
+	m.walk(0, VariantCombination{}, func(combination VariantCombination) {
+		if !m.Allowed(combination) {
+			return
+		}
+
+		seen[combination.String()] = true
+
+		combinations = append(combinations, combination)
+	})
+
+	for _, include := range m.Include {
+		if seen[include.String()] {
+			continue
+		}
+
+		seen[include.String()] = true
+
+		combinations = append(combinations, include)
+	}
+
+	return combinations
+}
+
+func (m *VariantMatrix) Allowed(combination VariantCombination) bool {
+	return m.skipReason(combination) == ""
+}
+
+// VariantSkip is a combination of the dimensions which the matrix skips, and the reason it was skipped.
+type VariantSkip struct {
+	Combination VariantCombination
+	Reason      string
+}
+
+// Skipped returns every combination of the dimensions which is matched by an Exclude rule or rejected by a
+// Constraint and isn't added back by the Include cases. The reason is the first rule or the name of the first
+// constraint which skipped it.
+func (m *VariantMatrix) Skipped() (skipped []VariantSkip) {
+	included := map[string]bool{}
+
+	for _, include := range m.Include {
+		included[include.String()] = true
+	}
+
+	m.walk(0, VariantCombination{}, func(combination VariantCombination) {
+		if reason := m.skipReason(combination); reason != "" && !included[combination.String()] {
+			skipped = append(skipped, VariantSkip{Combination: combination, Reason: reason})
+		}
+	})
+
+	return skipped
+}
+
+func (m *VariantMatrix) skipReason(combination VariantCombination) string {
+	for _, rule := range m.Exclude {
+		if combination.Matches(rule) {
+			return fmt.Sprintf("excluded by '%s'", rule)
+		}
+	}
+
+	for i, constraint := range m.Constraints {
+		if constraint.Allow(combination) {
+			continue
+		}
+
+		if constraint.Name == "" {
+			return fmt.Sprintf("rejected by constraint %d", i)
+		}
+
+		return constraint.Name
+	}
+
+	return ""
+}
+
+func (m *VariantMatrix) walk(i int, current VariantCombination, fn func(combination VariantCombination)) {
+	if i == len(m.Dimensions) {
+		combination := make(VariantCombination, len(current))
+
+		for key, value := range current {
+			combination[key] = value
+		}
+
+		fn(combination)
+
+		return
+	}
+
+	dimension := m.Dimensions[i]
+
+	for _, value := range dimension.Values {
+		current[dimension.Name] = value
+
+		m.walk(i+1, current, fn)
+	}
+
+	delete(current, dimension.Name)
+}
+
+func (m *VariantMatrix) Plans() (plans []*PlanMetadata, err error) {
+	return m.PlansFor(m.Combinations())
+}
+
+func (m *VariantMatrix) PlansFor(combinations []VariantCombination) (plans []*PlanMetadata, err error) {
+	if m.Build == nil {
+		return nil, fmt.Errorf("variant matrix has no plan builder")
+	}
+
+	var alias, description *template.Template
+
+	if alias, err = newVariantTemplate("alias", m.Alias); err != nil {
+		return nil, err
+	}
+
+	if description, err = newVariantTemplate("description", m.Description); err != nil {
+		return nil, err
+	}
+
+	for _, combination := range combinations {
+		var (
+			a, d string
+			plan *PlanMetadata
+		)
+
+		if a, err = executeVariantTemplate(alias, combination); err != nil {
+			return nil, err
+		}
+
+		if d, err = executeVariantTemplate(description, combination); err != nil {
+			return nil, err
+		}
+
+		if plan, err = m.Build(a, d, combination); err != nil {
+			return nil, fmt.Errorf("error building plan for variant '%s': %w", combination, err)
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+var variantTemplateFuncs = template.FuncMap{
+	"replace": strings.ReplaceAll,
+	"trimPrefix": func(prefix, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"responseTypeDescription":   responseTypeToFlowDescription,
+	"clientAuthTypeDescription": clientAuthTypeToDescription,
+}
+
+func newVariantTemplate(name, text string) (tmpl *template.Template, err error) {
+	if tmpl, err = template.New(name).Funcs(variantTemplateFuncs).Option("missingkey=zero").Parse(text); err != nil {
+		return nil, fmt.Errorf("error parsing %s template: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+func executeVariantTemplate(tmpl *template.Template, combination VariantCombination) (string, error) {
+	buf := &strings.Builder{}
+
+	if err := tmpl.Execute(buf, map[string]string(combination)); err != nil {
+		return "", fmt.Errorf("error executing %s template for variant '%s': %w", tmpl.Name(), combination, err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,235 @@
+package oidcc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVariantMatrixCombinations(t *testing.T) {
+	matrix := &VariantMatrix{
+		Dimensions: []VariantDimension{
+			{Name: "client_auth_type", Values: []string{"none", "client_secret_basic", "client_secret_jwt"}},
+			{Name: "alg", Values: []string{"", "HS256"}},
+		},
+		Exclude: []VariantCombination{
+			{"client_auth_type": "client_secret_basic", "alg": "HS256"},
+		},
+		Constraints: []VariantConstraint{
+			{
+				Name: "none has no secret alg",
+				Allow: func(combination VariantCombination) bool {
+					return combination["client_auth_type"] != "none" || combination["alg"] == ""
+				},
+			},
+		},
+		Include: []VariantCombination{
+			{"client_auth_type": "private_key_jwt", "alg": "RS256"},
+			{"client_auth_type": "none", "alg": ""},
+		},
+	}
+
+	expected := []string{
+		"alg=,client_auth_type=none",
+		"alg=,client_auth_type=client_secret_basic",
+		"alg=,client_auth_type=client_secret_jwt",
+		"alg=HS256,client_auth_type=client_secret_jwt",
+		"alg=RS256,client_auth_type=private_key_jwt",
+	}
+
+	combinations := matrix.Combinations()
+
+	if len(combinations) != len(expected) {
+		t.Fatalf("expected %d combinations but got %d: %v", len(expected), len(combinations), combinations)
+	}
+
+	for i, combination := range combinations {
+		if combination.String() != expected[i] {
+			t.Errorf("combination %d: expected '%s' but got '%s'", i, expected[i], combination)
+		}
+	}
+}
+
+func TestVariantMatrixSkipped(t *testing.T) {
+	matrix := &VariantMatrix{
+		Dimensions: []VariantDimension{
+			{Name: "client_auth_type", Values: []string{"none", "client_secret_basic"}},
+			{Name: "alg", Values: []string{"", "HS256"}},
+		},
+		Exclude: []VariantCombination{
+			{"client_auth_type": "client_secret_basic", "alg": "HS256"},
+		},
+		Constraints: []VariantConstraint{
+			{
+				Name: "none has no secret alg",
+				Allow: func(combination VariantCombination) bool {
+					return combination["client_auth_type"] != "none" || combination["alg"] == ""
+				},
+			},
+		},
+	}
+
+	skipped := matrix.Skipped()
+
+	if len(skipped) != 2 {
+		t.Fatalf("expected 2 skipped combinations but got %d: %v", len(skipped), skipped)
+	}
+
+	if skipped[0].Combination.String() != "alg=HS256,client_auth_type=none" || skipped[0].Reason != "none has no secret alg" {
+		t.Errorf("unexpected skipped combination %+v", skipped[0])
+	}
+
+	if skipped[1].Combination.String() != "alg=HS256,client_auth_type=client_secret_basic" || skipped[1].Reason != "excluded by 'alg=HS256,client_auth_type=client_secret_basic'" {
+		t.Errorf("unexpected skipped combination %+v", skipped[1])
+	}
+
+	matrix.Include = []VariantCombination{{"client_auth_type": "none", "alg": "HS256"}}
+
+	if skipped = matrix.Skipped(); len(skipped) != 1 {
+		t.Errorf("expected the included combination not to be skipped but got %v", skipped)
+	}
+}
+
+func TestVariantMatrixPlansTemplateError(t *testing.T) {
+	matrix := &VariantMatrix{
+		Dimensions: []VariantDimension{{Name: "response_type", Values: []string{"code"}}},
+		Alias:      "{{ .response_type",
+		Build: func(alias, description string, combination VariantCombination) (plan *PlanMetadata, err error) {
+			return &PlanMetadata{}, nil
+		},
+	}
+
+	if _, err := matrix.Plans(); err == nil || !strings.Contains(err.Error(), "error parsing alias template") {
+		t.Fatalf("expected alias template error but got %v", err)
+	}
+}
+
+func TestComprehensiveVariantMatrix(t *testing.T) {
+	plans, err := NewComprehensiveDiscoveryPlanAll("secret", "https://idp.example.com", SummaryPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plans) != len(clientAuthTypes)*len(responseTypes)*len(responseModes) {
+		t.Fatalf("expected %d plans but got %d", len(clientAuthTypes)*len(responseTypes)*len(responseModes), len(plans))
+	}
+
+	i := 0
+
+	for _, clientAuthType := range clientAuthTypes {
+		for _, responseType := range responseTypes {
+			for _, responseMode := range responseModes {
+				alias := fmt.Sprintf("conformance-%s-%s", strings.ReplaceAll(clientAuthType, "client_secret_", ""), strings.ReplaceAll(responseType, " ", "-"))
+				fp := ""
+
+				if responseMode == "form_post" {
+					alias += "formpost"
+					fp = " Form Post"
+				}
+
+				description := fmt.Sprintf("Comprehensive: %s %s%s", responseTypeToFlowDescription(responseType), clientAuthTypeToDescription(clientAuthType), fp)
+
+				plan := plans[i]
+
+				if plan.Config.Alias != alias {
+					t.Errorf("plan %d: expected alias '%s' but got '%s'", i, alias, plan.Config.Alias)
+				}
+
+				if plan.Config.Description != description {
+					t.Errorf("plan %d: expected description '%s' but got '%s'", i, description, plan.Config.Description)
+				}
+
+				variantClientAuthType := clientAuthType
+
+				if strings.HasSuffix(clientAuthType, "tls_client_auth") {
+					variantClientAuthType = "mtls"
+				}
+
+				if plan.Variant.ServerMetadata != "" || plan.Variant.ClientAuthType != variantClientAuthType || plan.Variant.ResponseType != responseType || plan.Variant.ResponseMode != responseMode {
+					t.Errorf("plan %d: unexpected variant %+v", i, plan.Variant)
+				}
+
+				switch clientAuthType {
+				case "none":
+					if plan.Config.Client.ClientSecret != "" {
+						t.Errorf("plan %d: expected no client secret", i)
+					}
+				case "client_secret_jwt":
+					if plan.Config.Client.ClientSecretJWTAlg != "HS256" {
+						t.Errorf("plan %d: expected HS256 client secret alg", i)
+					}
+				case "private_key_jwt":
+					for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2} {
+						if client.ClientSecret != "" || client.JWKS == nil || len(client.JWKS.Keys) != 1 || client.JWKS.Keys[0].D == "" {
+							t.Errorf("plan %d: expected a private key and no client secret", i)
+						}
+					}
+				case "tls_client_auth", "self_signed_tls_client_auth":
+					if plan.Config.Client.ClientSecret != "" || plan.Config.MTLS == nil || plan.Config.MTLS2 == nil || plan.Config.MTLS.SelfSigned() != (clientAuthType == "self_signed_tls_client_auth") {
+						t.Errorf("plan %d: expected client certificates and no client secret", i)
+					}
+				default:
+					if plan.Config.Client.ClientSecret != "secret" {
+						t.Errorf("plan %d: expected client secret", i)
+					}
+				}
+
+				i++
+			}
+		}
+	}
+}
+
+func TestVariantMatrixSamplePairwise(t *testing.T) {
+	matrix := NewComprehensiveVariantMatrix("secret", "https://idp.example.com", SummaryPublish)
+
+	matrix.Exclude = []VariantCombination{{"client_auth_type": "none", "response_type": "code token"}}
+
+	combinations, err := matrix.Sample(2, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full := matrix.Combinations()
+
+	if len(combinations) >= len(full) {
+		t.Fatalf("expected fewer than %d combinations but got %d", len(full), len(combinations))
+	}
+
+	covered := map[string]bool{}
+
+	for _, combination := range combinations {
+		if !matrix.Allowed(combination) {
+			t.Errorf("sampled combination '%s' is not allowed", combination)
+		}
+
+		for _, tuple := range matrix.tuples(combination, dimensionSubsets(len(matrix.Dimensions), 2)) {
+			covered[tuple] = true
+		}
+	}
+
+	for _, combination := range full {
+		for _, tuple := range matrix.tuples(combination, dimensionSubsets(len(matrix.Dimensions), 2)) {
+			if !covered[tuple] {
+				t.Errorf("pair '%s' is not covered", tuple)
+			}
+		}
+	}
+
+	again, err := matrix.Sample(2, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(again) != fmt.Sprint(combinations) {
+		t.Errorf("expected the same sample for the same seed")
+	}
+
+	if combinations, err = matrix.Sample(0, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(combinations) != len(full) {
+		t.Errorf("expected strength 0 to return the full matrix of %d but got %d", len(full), len(combinations))
+	}
+}
@@ -1,8 +1,11 @@
 package oidcc
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,13 +34,17 @@ type PlanMetadata struct {
 }
 
 type PlanConfig struct {
-	Alias            string      `json:"alias,omitempty"`
-	Description      string      `json:"description,omitempty"`
-	Publish          string      `json:"publish,omitempty"`
-	Server           *PlanServer `json:"server,omitempty"`
-	Client           *PlanClient `json:"client,omitempty"`
-	Client2          *PlanClient `json:"client2,omitempty"`
-	ClientSecretPost *PlanClient `json:"client_secret_post,omitempty"`
+	Alias            string            `json:"alias,omitempty"`
+	Description      string            `json:"description,omitempty"`
+	Publish          string            `json:"publish,omitempty"`
+	Server           *PlanServer       `json:"server,omitempty"`
+	Client           *PlanClient       `json:"client,omitempty"`
+	Client2          *PlanClient       `json:"client2,omitempty"`
+	ClientSecretPost *PlanClient       `json:"client_secret_post,omitempty"`
+	MTLS             *PlanMTLS         `json:"mtls,omitempty"`
+	MTLS2            *PlanMTLS         `json:"mtls2,omitempty"`
+	Resource         *PlanResource     `json:"resource,omitempty"`
+	Registration     *PlanRegistration `json:"registration,omitempty"`
 }
 
 type PlanOwner struct {
@@ -51,6 +58,27 @@ type PlanModule struct {
 	Instances  []any        `json:"instances"`
 }
 
+// InstanceIDs returns the IDs of the test instances which have been created for the module from oldest to newest.
+func (m PlanModule) InstanceIDs() (ids []string) {
+	for _, instance := range m.Instances {
+		if id, ok := instance.(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// LatestInstanceID returns the ID of the most recently created test instance for the module.
+func (m PlanModule) LatestInstanceID() string {
+	ids := m.InstanceIDs()
+	if len(ids) == 0 {
+		return ""
+	}
+
+	return ids[len(ids)-1]
+}
+
 type PlanPage struct {
 	Draw   int    `json:"draw"`
 	Start  int    `json:"start"`
@@ -69,21 +97,6 @@ type PlanCreateResponseModule struct {
 	TestModule string `json:"testModule"`
 }
 
-type Client struct {
-	ClientID                string   `yaml:"client_id"`
-	ClientSecret            string   `yaml:"client_secret,omitempty"`
-	Public                  bool     `yaml:"public"`
-	RedirectURIs            []string `yaml:"redirect_uris"`
-	Scopes                  []string `yaml:"scopes"`
-	GrantTypes              []string `yaml:"grant_types"`
-	ResponseTypes           []string `yaml:"response_types"`
-	ResponseModes           []string `yaml:"response_modes"`
-	AuthorizationPolicy     string   `yaml:"authorization_policy"`
-	ConsentMode             string   `yaml:"consent_mode"`
-	RequestObjectSigningAlg string   `yaml:"request_object_signing_alg"`
-	TokenEndpointAuthMethod string   `yaml:"token_endpoint_auth_method"`
-}
-
 type PlanServer struct {
 	ACRValues             string `json:"acr_values,omitempty"`
 	AuthorizationEndpoint string `json:"authorization_endpoint,omitempty"`
@@ -91,181 +104,323 @@ type PlanServer struct {
 	UserinfoEndpoint      string `json:"userinfo_endpoint"`
 	Issuer                string `json:"issuer,omitempty"`
 	JSONWebKeysURI        string `json:"jwks_uri,omitempty"`
+	RegistrationEndpoint  string `json:"registration_endpoint,omitempty"`
 	DiscoveryURL          string `json:"discoveryUrl,omitempty"`
 	LoginHint             string `json:"login_hint,omitempty"`
 }
 
 type PlanClient struct {
-	ClientID           string `json:"client_id,omitempty"`
-	ClientSecret       string `json:"client_secret,omitempty"`
-	ClientSecretJWTAlg string `json:"client_secret_jwt_alg,omitempty"`
+	ClientID           string         `json:"client_id,omitempty"`
+	ClientSecret       string         `json:"client_secret,omitempty"`
+	ClientSecretJWTAlg string         `json:"client_secret_jwt_alg,omitempty"`
+	RedirectURI        string         `json:"redirect_uri,omitempty"`
+	Scope              string         `json:"scope,omitempty"`
+	JWKS               *JSONWebKeySet `json:"jwks,omitempty"`
+}
+
+type PlanResource struct {
+	ResourceURL string `json:"resourceUrl,omitempty"`
 }
 
 type PlanVariant struct {
-	ServerMetadata     string `json:"server_metadata,omitempty"`
-	ClientRegistration string `json:"client_registration,omitempty"`
-	ClientAuthType     string `json:"client_auth_type,omitempty"`
-	ResponseType       string `json:"response_type,omitempty"`
-	ResponseMode       string `json:"response_mode,omitempty"`
+	ServerMetadata        string `json:"server_metadata,omitempty"`
+	ClientRegistration    string `json:"client_registration,omitempty"`
+	ClientAuthType        string `json:"client_auth_type,omitempty"`
+	ResponseType          string `json:"response_type,omitempty"`
+	ResponseMode          string `json:"response_mode,omitempty"`
+	FAPIProfile           string `json:"fapi_profile,omitempty"`
+	FAPIResponseMode      string `json:"fapi_response_mode,omitempty"`
+	FAPIAuthRequestMethod string `json:"fapi_auth_request_method,omitempty"`
+	FAPIClientType        string `json:"fapi_client_type,omitempty"`
+	FAPIRequestMethod     string `json:"fapi_request_method,omitempty"`
+	SenderConstrain       string `json:"sender_constrain,omitempty"`
+	OpenID                string `json:"openid,omitempty"`
+}
+
+// ClientOptions adjusts the clients returned by GetClientsWithOptions.
+type ClientOptions struct {
+	// HashSecrets writes the client secrets as PBKDF2-SHA512 digests instead of plaintext. The plan config always keeps
+	// the plaintext secret as the suite requires it, and client_secret_jwt clients keep the plaintext secret as the
+	// provider needs it to verify the HMAC of the assertion.
+	HashSecrets bool
+
+	// HashIterations is the number of PBKDF2 iterations, if zero the DefaultPBKDF2Iterations are used.
+	HashIterations int
+
+	// SecretDigests are the client_secret digests of the existing clients by client ID, such as those returned by
+	// ClientSecretDigestsFile. When hashing, an existing digest with the same iterations is kept if the secret still
+	// matches it, so hashing the same secrets again with a new salt doesn't rewrite every digest.
+	SecretDigests map[string]string
+
+	// JWKSURI is the https base URL of a handler from NewJWKSHandler. If set private_key_jwt clients are registered
+	// with a jwks_uri below it instead of the public keys in jwks.
+	JWKSURI string
+}
+
+// existingSecretDigest returns the digest of the client in the SecretDigests if it's a PBKDF2-SHA512 digest with the
+// HashIterations which matches the secret of the client.
+func (o ClientOptions) existingSecretDigest(pc *PlanClient) (digest string, ok bool) {
+	iterations := o.HashIterations
+
+	if iterations == 0 {
+		iterations = DefaultPBKDF2Iterations
+	}
+
+	if digest, ok = o.SecretDigests[pc.ClientID]; !ok || !strings.HasPrefix(digest, "$pbkdf2-sha512$"+strconv.Itoa(iterations)+"$") {
+		return "", false
+	}
+
+	if ok, _ = VerifyClientSecret(digest, pc.ClientSecret); !ok {
+		return "", false
+	}
+
+	return digest, true
+}
+
+// GetClients returns the identity provider clients required by the plan. The response types, grant types, and token
+// endpoint authentication method are derived from the plan variant, or for the certification profile plans which do
+// not have a response type variant from the response types the profile tests. Plans using dynamic client registration
+// and the relying party plans have no clients. If a client can't be generated no clients are returned,
+// GetClientsWithOptions returns the reason.
+//
+// The request_uris, audience, lifespan, requested_audience_mode, and pre_configured_consent_duration options don't
+// follow from the plan and are left empty for the user to set. The suite serves a new request_uri for every request
+// object it passes by reference, so there are no fixed request URIs to register for the request_uri modules.
+func (p PlanMetadata) GetClients(root *url.URL) (clients []Client) {
+	clients, _ = p.GetClientsWithOptions(root, ClientOptions{})
+
+	return clients
 }
 
-func (p PlanMetadata) GetClients(root *url.URL) []Client {
-	var clients []Client
+// GetClientsWithOptions returns the identity provider clients required by the plan like GetClients with the options
+// applied.
+func (p PlanMetadata) GetClientsWithOptions(root *url.URL, opts ClientOptions) (clients []Client, err error) {
+	if p.Config == nil || p.IsDynamic() || p.IsRP() {
+		return nil, nil
+	}
 
 	redirectURI := root.JoinPath("test", "a", p.Config.Alias, "callback")
 
-	if p.Config.Client != nil {
-		client := Client{
-			ClientID:     p.Config.Client.ClientID,
-			ClientSecret: fmt.Sprintf("$plaintext$%s", p.Config.Client.ClientSecret),
-			Public:       false,
-			RedirectURIs: []string{
-				redirectURI.String(),
-			},
-			Scopes: []string{
-				"openid",
-				"offline_access",
-				"profile",
-				"email",
-				"phone",
-				"address",
-				"all",
-			},
-			GrantTypes: []string{
-				"authorization_code",
-				"refresh_token",
-			},
-			ResponseTypes: []string{
-				"code",
-			},
-			ResponseModes: []string{
-				"form_post",
-				"query",
-				"fragment",
-				"jwt",
-				"form_post.jwt",
-				"query.jwt",
-				"fragment.jwt",
-			},
-			AuthorizationPolicy:     "one_factor",
-			ConsentMode:             "implicit",
-			TokenEndpointAuthMethod: "client_secret_basic",
-			RequestObjectSigningAlg: "none",
+	authMethod := "client_secret_basic"
+
+	if p.Variant != nil && p.Variant.ClientAuthType != "" {
+		authMethod = p.Variant.ClientAuthType
+	}
+
+	responseTypes := p.ResponseTypes()
+
+	add := func(pc *PlanClient, authMethod string, mtls *PlanMTLS) error {
+		if pc == nil {
+			return nil
 		}
 
-		switch {
-		case strings.Contains(p.Name, "-hybrid-"):
-			client.ResponseTypes = append(client.ResponseTypes, "code id_token", "code token", "code id_token token")
-			client.GrantTypes = append(client.GrantTypes, "implicit")
-		case strings.Contains(p.Name, "-implicit-"):
-			client.ResponseTypes = append(client.ResponseTypes, "id_token", "token", "id_token token")
-			client.GrantTypes = append(client.GrantTypes, "implicit")
+		if authMethod == "mtls" {
+			switch {
+			case mtls == nil:
+				return fmt.Errorf("client '%s': the plan has no mtls config for the client", pc.ClientID)
+			case mtls.SelfSigned():
+				authMethod = "self_signed_tls_client_auth"
+			default:
+				authMethod = "tls_client_auth"
+			}
 		}
 
+		client, err := newClient(pc, authMethod, redirectURI, responseTypes, mtls, opts)
+		if err != nil {
+			return err
+		}
+
+		p.applyFAPI(&client)
+		p.applyLogout(&client, root)
+
 		clients = append(clients, client)
+
+		return nil
 	}
 
-	if p.Config.Client2 != nil {
-		client := Client{
-			ClientID:     p.Config.Client2.ClientID,
-			ClientSecret: fmt.Sprintf("$plaintext$%s", p.Config.Client2.ClientSecret),
-			Public:       false,
-			RedirectURIs: []string{
-				redirectURI.String(),
-			},
-			Scopes: []string{
-				"openid",
-				"offline_access",
-				"profile",
-				"email",
-				"phone",
-				"address",
-				"all",
-			},
-			GrantTypes: []string{
-				"authorization_code",
-				"refresh_token",
-			},
-			ResponseTypes: []string{
-				"code",
-			},
-			ResponseModes: []string{
-				"form_post",
-				"query",
-				"fragment",
-				"jwt",
-				"form_post.jwt",
-				"query.jwt",
-				"fragment.jwt",
-			},
-			AuthorizationPolicy:     "one_factor",
-			ConsentMode:             "implicit",
-			TokenEndpointAuthMethod: "client_secret_basic",
-			RequestObjectSigningAlg: "none",
-		}
+	if err = add(p.Config.Client, authMethod, p.Config.MTLS); err != nil {
+		return nil, err
+	}
+
+	if err = add(p.Config.Client2, authMethod, p.Config.MTLS2); err != nil {
+		return nil, err
+	}
+
+	if err = add(p.Config.ClientSecretPost, "client_secret_post", nil); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
 
-		switch {
-		case strings.Contains(p.Name, "-hybrid-"):
-			client.ResponseTypes = append(client.ResponseTypes, "code id_token", "code token", "code id_token token")
-			client.GrantTypes = append(client.GrantTypes, "implicit")
-		case strings.Contains(p.Name, "-implicit-"):
-			client.ResponseTypes = append(client.ResponseTypes, "id_token", "token", "id_token token")
-			client.GrantTypes = append(client.GrantTypes, "implicit")
+var certificationProfileResponseTypes = map[string][]string{
+	"oidcc-basic-certification-test-plan":             {"code"},
+	"oidcc-formpost-basic-certification-test-plan":    {"code"},
+	"oidcc-hybrid-certification-test-plan":            {"code id_token", "code token", "code id_token token"},
+	"oidcc-formpost-hybrid-certification-test-plan":   {"code id_token", "code token", "code id_token token"},
+	"oidcc-implicit-certification-test-plan":          {"id_token", "id_token token"},
+	"oidcc-formpost-implicit-certification-test-plan": {"id_token", "id_token token"},
+}
+
+// ResponseTypes returns the response types the plan uses.
+func (p PlanMetadata) ResponseTypes() []string {
+	if p.Variant != nil && p.Variant.ResponseType != "" {
+		return []string{p.Variant.ResponseType}
+	}
+
+	if p.IsFAPI() {
+		return p.fapiResponseTypes()
+	}
+
+	if responseTypes, ok := certificationProfileResponseTypes[p.Name]; ok {
+		return responseTypes
+	}
+
+	return []string{"code"}
+}
+
+// grantTypesForResponseTypes returns the grant types required by the response types. The authorization_code and
+// refresh_token grants are required by any response type which returns a code, and the implicit grant is required by
+// any response type which returns an id_token or token from the authorization endpoint.
+func grantTypesForResponseTypes(responseTypes []string) (grantTypes []string) {
+	var code, implicit bool
+
+	for _, responseType := range responseTypes {
+		for _, value := range strings.Fields(responseType) {
+			switch value {
+			case "code":
+				code = true
+			case "id_token", "token":
+				implicit = true
+			}
 		}
+	}
 
-		clients = append(clients, client)
+	if code {
+		grantTypes = append(grantTypes, "authorization_code")
+	}
+
+	if implicit {
+		grantTypes = append(grantTypes, "implicit")
+	}
+
+	if code {
+		grantTypes = append(grantTypes, "refresh_token")
+	}
+
+	return grantTypes
+}
+
+func newClient(pc *PlanClient, authMethod string, redirectURI *url.URL, responseTypes []string, mtls *PlanMTLS, opts ClientOptions) (client Client, err error) {
+	grantTypes := grantTypesForResponseTypes(responseTypes)
+
+	client = Client{
+		ClientID: pc.ClientID,
+		RedirectURIs: []string{
+			redirectURI.String(),
+		},
+		Scopes: []string{
+			"openid",
+			"profile",
+			"email",
+			"phone",
+			"address",
+			"all",
+		},
+		GrantTypes:    grantTypes,
+		ResponseTypes: append([]string(nil), responseTypes...),
+		ResponseModes: []string{
+			"form_post",
+			"query",
+			"fragment",
+			"jwt",
+			"form_post.jwt",
+			"query.jwt",
+			"fragment.jwt",
+		},
+		AuthorizationPolicy:       "one_factor",
+		ConsentMode:               "implicit",
+		IDTokenSignedResponseAlg:  "RS256",
+		UserinfoSignedResponseAlg: "none",
+		TokenEndpointAuthMethod:   authMethod,
+		RequestObjectSigningAlg:   "none",
+	}
+
+	if slices.Contains(grantTypes, "refresh_token") {
+		client.Scopes = slices.Insert(client.Scopes, 1, "offline_access")
 	}
 
-	if p.Config.ClientSecretPost != nil {
-		client := Client{
-			ClientID:     p.Config.ClientSecretPost.ClientID,
-			ClientSecret: fmt.Sprintf("$plaintext$%s", p.Config.ClientSecretPost.ClientSecret),
-			Public:       false,
-			RedirectURIs: []string{
-				redirectURI.String(),
-			},
-			Scopes: []string{
-				"openid",
-				"offline_access",
-				"profile",
-				"email",
-				"phone",
-				"address",
-				"all",
-			},
-			GrantTypes: []string{
-				"authorization_code",
-				"refresh_token",
-			},
-			ResponseTypes: []string{
-				"code",
-			},
-			ResponseModes: []string{
-				"form_post",
-				"query",
-				"fragment",
-				"jwt",
-				"form_post.jwt",
-				"query.jwt",
-				"fragment.jwt",
-			},
-			AuthorizationPolicy:     "one_factor",
-			ConsentMode:             "implicit",
-			TokenEndpointAuthMethod: "client_secret_post",
-			RequestObjectSigningAlg: "none",
+	switch authMethod {
+	case "none":
+		client.Public = true
+		client.RequirePKCE = true
+		client.PKCEChallengeMethod = "S256"
+	case "client_secret_jwt":
+		client.ClientSecret = fmt.Sprintf("$plaintext$%s", pc.ClientSecret)
+		client.TokenEndpointAuthSigningAlg = pc.ClientSecretJWTAlg
+
+		if client.TokenEndpointAuthSigningAlg == "" {
+			client.TokenEndpointAuthSigningAlg = "HS256"
+		}
+	case "private_key_jwt":
+		if pc.JWKS == nil || len(pc.JWKS.Keys) == 0 {
+			return client, fmt.Errorf("client '%s': the client has no keys for private_key_jwt", pc.ClientID)
 		}
 
-		switch {
-		case strings.Contains(p.Name, "-hybrid-"):
-			client.ResponseTypes = append(client.ResponseTypes, "code id_token", "code token", "code id_token token")
-			client.GrantTypes = append(client.GrantTypes, "implicit")
-		case strings.Contains(p.Name, "-implicit-"):
-			client.ResponseTypes = append(client.ResponseTypes, "id_token", "token", "id_token token")
-			client.GrantTypes = append(client.GrantTypes, "implicit")
+		client.TokenEndpointAuthSigningAlg = pc.JWKS.Keys[0].Algorithm
+
+		if opts.JWKSURI != "" {
+			client.JSONWebKeysURI = clientJWKSURI(opts.JWKSURI, pc.ClientID)
+
+			break
 		}
 
-		clients = append(clients, client)
+		for _, key := range pc.JWKS.Keys {
+			var public string
+
+			if public, err = key.PublicKeyPEM(); err != nil {
+				return client, fmt.Errorf("client '%s': %w", pc.ClientID, err)
+			}
+
+			client.JSONWebKeys = append(client.JSONWebKeys, ClientJWK{KeyID: key.KeyID, Algorithm: key.Algorithm, Use: "sig", Key: public})
+		}
+	case "tls_client_auth", "self_signed_tls_client_auth":
+		var certificate *x509.Certificate
+
+		if certificate, err = mtls.Certificate(); err != nil {
+			return client, fmt.Errorf("client '%s': %w", pc.ClientID, err)
+		}
+
+		if authMethod == "tls_client_auth" {
+			client.TLSClientAuthSubjectDN = certificate.Subject.String()
+
+			break
+		}
+
+		var key ClientJWK
+
+		if key, err = newCertificateClientJWK(certificate); err != nil {
+			return client, fmt.Errorf("client '%s': %w", pc.ClientID, err)
+		}
+
+		client.JSONWebKeys = append(client.JSONWebKeys, key)
+	default:
+		if opts.HashSecrets {
+			if digest, ok := opts.existingSecretDigest(pc); ok {
+				client.ClientSecret = digest
+
+				break
+			}
+
+			if client.ClientSecret, err = HashClientSecret(pc.ClientSecret, opts.HashIterations); err != nil {
+				return client, fmt.Errorf("client '%s': %w", pc.ClientID, err)
+			}
+
+			break
+		}
+
+		client.ClientSecret = fmt.Sprintf("$plaintext$%s", pc.ClientSecret)
 	}
 
-	return clients
+	return client, nil
 }
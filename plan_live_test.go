@@ -0,0 +1,151 @@
+//go:build live
+
+package oidcc
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The live tests run against a conformance suite and require the OIDCC_ISSUER and OIDCC_SECRET environment variables.
+// The suite is at OIDCC_URL, https://localhost:8443/api by default, and OIDCC_TOKEN is sent as the bearer token if set.
+// Run them with: go test -tags live ./...
+func liveIssuerAndSecret(t *testing.T) (issuer, secret string) {
+	t.Helper()
+
+	issuer, secret = os.Getenv("OIDCC_ISSUER"), os.Getenv("OIDCC_SECRET")
+
+	if issuer == "" || secret == "" {
+		t.Skip("OIDCC_ISSUER and OIDCC_SECRET are required for the live tests")
+	}
+
+	return issuer, secret
+}
+
+// liveAPIClient returns a client for the suite at OIDCC_URL using the OIDCC_TOKEN, and the suite URL without the /api
+// path which is the root of the redirect URIs.
+func liveAPIClient(t *testing.T) (client *APIClient, root *url.URL) {
+	t.Helper()
+
+	raw := os.Getenv("OIDCC_URL")
+
+	if raw == "" {
+		raw = "https://localhost:8443/api"
+	}
+
+	api, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("error parsing OIDCC_URL: %v", err)
+	}
+
+	headers := http.Header{}
+
+	if token := os.Getenv("OIDCC_TOKEN"); token != "" {
+		headers.Set("Authorization", "Bearer "+token)
+	}
+
+	root = &url.URL{Scheme: api.Scheme, Host: api.Host, Path: strings.TrimSuffix(strings.TrimSuffix(api.Path, "/"), "/api")}
+
+	return NewAPIClient(api, headers, nil), root
+}
+
+func TestDeleteComprehensive(t *testing.T) {
+	liveIssuerAndSecret(t)
+
+	client, _ := liveAPIClient(t)
+
+	plans, err := client.GetPlans(0, 0, 100, false, "Comprehensive:", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, plan := range plans.Data {
+		if _, err = client.DeletePlan(plan); err != nil {
+			t.Errorf("error deleting plan '%s': %v", plan.ID, err)
+		}
+	}
+}
+
+func TestCreateComprehensive(t *testing.T) {
+	var (
+		plans []*PlanMetadata
+		err   error
+	)
+
+	issuer, secret := liveIssuerAndSecret(t)
+
+	client, _ := liveAPIClient(t)
+
+	if plans, err = NewComprehensiveDiscoveryPlanAll(secret, issuer, SummaryPublish); err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := client.PostPlans(plans...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log(responses)
+}
+
+func TestCreate(t *testing.T) {
+	var (
+		plans []*PlanMetadata
+		err   error
+	)
+
+	issuer, secret := liveIssuerAndSecret(t)
+
+	client, _ := liveAPIClient(t)
+
+	if _, err = client.DeletePlans(); err != nil {
+		t.Fatal(err)
+	}
+
+	if plans, err = NewPlansAll(issuer, secret, SummaryPublish); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Reverse(plans)
+
+	responses, err := client.PostPlans(plans...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log(responses)
+}
+
+func TestGetAndMarshalClients(t *testing.T) {
+	liveIssuerAndSecret(t)
+
+	client, root := liveAPIClient(t)
+
+	plans, err := client.GetPlans(0, 0, 40, false, "Comprehensive: ", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var clients []Client
+
+	for _, plan := range plans.Data {
+		var generated []Client
+
+		if generated, err = plan.GetClientsWithOptions(root, ClientOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		clients = append(clients, generated...)
+	}
+
+	out, err := RenderClients(clients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log(string(out))
+}
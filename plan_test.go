@@ -1,110 +1,275 @@
 package oidcc
 
 import (
-	"fmt"
-	"gopkg.in/yaml.v3"
+	"encoding/json"
 	"net/url"
 	"slices"
+	"strings"
 	"testing"
 )
 
-func TestDeleteComprehensive(t *testing.T) {
-	client := NewAPIClient(nil, nil, nil)
-
-	plans, err := client.GetPlans(0, 0, 100, false, "Comprehensive:", "")
+func TestNewPlansAll(t *testing.T) {
+	plans, err := NewPlansAll("https://idp.example.com", "secret", SummaryPublish)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		name, alias, description string
+		clients                  int
+	}{
+		{"oidcc-basic-certification-test-plan", "certification-profile-basic", "Certification Profile: Basic", 3},
+		{"oidcc-hybrid-certification-test-plan", "certification-profile-hybrid", "Certification Profile: Hybrid", 3},
+		{"oidcc-implicit-certification-test-plan", "certification-profile-implicit", "Certification Profile: Implicit", 1},
+		{"oidcc-formpost-basic-certification-test-plan", "certification-profile-formpost-basic", "Certification Profile: Form Post Basic", 3},
+		{"oidcc-formpost-hybrid-certification-test-plan", "certification-profile-formpost-hybrid", "Certification Profile: Form Post Hybrid", 3},
+		{"oidcc-formpost-implicit-certification-test-plan", "certification-profile-formpost-implicit", "Certification Profile: Form Post Implicit", 1},
+		{"oidcc-config-certification-test-plan", "certification-profile-config", "Certification Profile: Config", 0},
+	}
+
+	if len(plans) != len(expected) {
+		t.Fatalf("expected %d plans but got %d", len(expected), len(plans))
 	}
 
-	for _, plan := range plans.Data {
-		if _, err = client.DeletePlan(plan); err != nil {
+	for i, plan := range plans {
+		e := expected[i]
+
+		if plan.Name != e.name || plan.Config.Alias != e.alias || plan.Config.Description != e.description {
+			t.Errorf("plan %d: unexpected plan %s %s %s", i, plan.Name, plan.Config.Alias, plan.Config.Description)
+		}
+
+		if plan.Publish != "summary" {
+			t.Errorf("plan %d: expected publish summary but got '%s'", i, plan.Publish)
+		}
+
+		if plan.Config.Server.DiscoveryURL != "https://idp.example.com/.well-known/openid-configuration" {
+			t.Errorf("plan %d: unexpected discovery url '%s'", i, plan.Config.Server.DiscoveryURL)
+		}
+
+		clients := 0
+
+		for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2, plan.Config.ClientSecretPost} {
+			if client == nil {
+				continue
+			}
+
+			clients++
 
+			if !strings.HasPrefix(client.ClientID, "conformance-"+e.alias+"-") || client.ClientSecret != "secret" {
+				t.Errorf("plan %d: unexpected client %+v", i, client)
+			}
+		}
+
+		if clients != e.clients {
+			t.Errorf("plan %d: expected %d clients but got %d", i, e.clients, clients)
 		}
 	}
 }
 
-func TestCreateComprehensive(t *testing.T) {
-	var (
-		plans []*PlanMetadata
-		err   error
-	)
+func TestNewPlanDiscoveryInvalidIssuer(t *testing.T) {
+	if _, err := NewPlansAll("not a url", "secret", NoPublish); err == nil {
+		t.Error("expected an error for an invalid issuer")
+	}
+}
 
-	issuer := "https://auth.jameselliott.dev"
-	secret := "dzT3itYvQMLAPPdvYmfRLyyVgvQe9tswEsDNw4dPpMzooSCL72ucYBpxLhPo"
+func TestPublishString(t *testing.T) {
+	for publish, expected := range map[Publish]string{NoPublish: "", SummaryPublish: "summary", EverythingPublish: "everything"} {
+		if publish.String() != expected {
+			t.Errorf("expected '%s' but got '%s'", expected, publish.String())
+		}
+	}
+}
 
-	client := NewAPIClient(nil, nil, nil)
+func TestPlanMetadataGetClients(t *testing.T) {
+	root := &url.URL{Scheme: "https", Host: "localhost:8443"}
 
-	if plans, err = NewComprehensiveDiscoveryPlanAll(secret, issuer, SummaryPublish); err != nil {
-		panic(err)
+	plans, err := NewPlansAll("https://idp.example.com", "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	responses, err := client.PostPlans(plans...)
-	if err != nil {
-		panic(err)
+	basic := plans[0].GetClients(root)
+
+	if len(basic) != 3 {
+		t.Fatalf("expected 3 clients but got %d", len(basic))
 	}
 
-	fmt.Println(responses)
-}
+	for i, method := range []string{"client_secret_basic", "client_secret_basic", "client_secret_post"} {
+		client := basic[i]
 
-func TestCreate(t *testing.T) {
-	var (
-		plans []*PlanMetadata
-		err   error
-	)
+		if client.TokenEndpointAuthMethod != method {
+			t.Errorf("client %d: expected auth method '%s' but got '%s'", i, method, client.TokenEndpointAuthMethod)
+		}
 
-	issuer := "https://auth.jameselliott.dev"
-	secret := "dzT3itYvQMLAPPdvYmfRLyyVgvQe9tswEsDNw4dPpMzooSCL72ucYBpxLhPo"
+		if client.ClientSecret != "$plaintext$secret" || client.Public {
+			t.Errorf("client %d: unexpected secret '%s'", i, client.ClientSecret)
+		}
 
-	client := NewAPIClient(nil, nil, nil)
+		if len(client.RedirectURIs) != 1 || client.RedirectURIs[0] != "https://localhost:8443/test/a/certification-profile-basic/callback" {
+			t.Errorf("client %d: unexpected redirect uris %v", i, client.RedirectURIs)
+		}
 
-	if _, err = client.DeletePlans(); err != nil {
-		panic(err)
+		if strings.Join(client.ResponseTypes, ",") != "code" {
+			t.Errorf("client %d: unexpected response types %v", i, client.ResponseTypes)
+		}
 	}
 
-	if plans, err = NewPlansAll(issuer, secret, SummaryPublish); err != nil {
-		panic(err)
+	hybrid := plans[1].GetClients(root)
+
+	if strings.Join(hybrid[0].ResponseTypes, ",") != "code id_token,code token,code id_token token" || strings.Join(hybrid[0].GrantTypes, ",") != "authorization_code,implicit,refresh_token" {
+		t.Errorf("unexpected hybrid client %+v", hybrid[0])
 	}
 
-	slices.Reverse(plans)
+	implicit := plans[2].GetClients(root)
 
-	responses, err := client.PostPlans(plans...)
-	if err != nil {
-		panic(err)
+	if len(implicit) != 1 || strings.Join(implicit[0].ResponseTypes, ",") != "id_token,id_token token" || strings.Join(implicit[0].GrantTypes, ",") != "implicit" || slices.Contains(implicit[0].Scopes, "offline_access") {
+		t.Errorf("unexpected implicit client %+v", implicit[0])
 	}
 
-	fmt.Println(responses)
+	if clients := plans[6].GetClients(root); len(clients) != 0 {
+		t.Errorf("expected no clients for the config plan but got %d", len(clients))
+	}
 }
 
-func TestGetAndMarshalClients(t *testing.T) {
-	client := NewAPIClient(nil, nil, nil)
+func TestPlanMetadataGetClientsFromVariant(t *testing.T) {
+	root := &url.URL{Scheme: "https", Host: "localhost:8443"}
 
-	plans, err := client.GetPlans(0, 0, 40, false, "Comprehensive: ", "")
+	plans, err := NewComprehensiveDiscoveryPlanAll("secret", "https://idp.example.com", NoPublish)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
 
-	var clients []Client
+	for _, plan := range plans {
+		clients := plan.GetClients(root)
+
+		if len(clients) != 2 {
+			t.Fatalf("%s: expected 2 clients but got %d", plan.Config.Alias, len(clients))
+		}
+
+		for _, client := range clients {
+			switch plan.Variant.ClientAuthType {
+			case "mtls":
+				if !strings.HasSuffix(client.TokenEndpointAuthMethod, "tls_client_auth") || plan.Config.MTLS.SelfSigned() != (client.TokenEndpointAuthMethod == "self_signed_tls_client_auth") {
+					t.Errorf("%s: unexpected auth method '%s' for the mtls config", plan.Config.Alias, client.TokenEndpointAuthMethod)
+				}
+			default:
+				if client.TokenEndpointAuthMethod != plan.Variant.ClientAuthType {
+					t.Errorf("%s: expected auth method '%s' but got '%s'", plan.Config.Alias, plan.Variant.ClientAuthType, client.TokenEndpointAuthMethod)
+				}
+			}
 
-	for _, plan := range plans.Data {
-		clients = append(clients, plan.GetClients(&url.URL{Scheme: "https", Host: "localhost:8443"})...)
+			if strings.Join(client.ResponseTypes, ",") != plan.Variant.ResponseType {
+				t.Errorf("%s: expected response types '%s' but got %v", plan.Config.Alias, plan.Variant.ResponseType, client.ResponseTypes)
+			}
+
+			switch plan.Variant.ClientAuthType {
+			case "none":
+				if !client.Public || client.ClientSecret != "" {
+					t.Errorf("%s: expected a public client without a secret but got %+v", plan.Config.Alias, client)
+				}
+			case "client_secret_jwt":
+				if client.Public || client.ClientSecret != "$plaintext$secret" || client.TokenEndpointAuthSigningAlg != "HS256" {
+					t.Errorf("%s: expected a confidential client with an HS256 secret but got %+v", plan.Config.Alias, client)
+				}
+			case "private_key_jwt":
+				if client.Public || client.ClientSecret != "" || client.TokenEndpointAuthSigningAlg != "ES256" || len(client.JSONWebKeys) != 1 {
+					t.Errorf("%s: expected a confidential client with an ES256 key but got %+v", plan.Config.Alias, client)
+				}
+			case "mtls":
+				if client.Public || client.ClientSecret != "" || (client.TLSClientAuthSubjectDN == "") == (len(client.JSONWebKeys) == 0) {
+					t.Errorf("%s: expected a confidential client with a subject dn or certificate but got %+v", plan.Config.Alias, client)
+				}
+			default:
+				if client.Public || client.ClientSecret != "$plaintext$secret" || client.TokenEndpointAuthSigningAlg != "" {
+					t.Errorf("%s: expected a confidential client but got %+v", plan.Config.Alias, client)
+				}
+			}
+
+			expected := map[string]string{
+				"code":                "authorization_code,refresh_token",
+				"id_token":            "implicit",
+				"id_token token":      "implicit",
+				"code id_token":       "authorization_code,implicit,refresh_token",
+				"code token":          "authorization_code,implicit,refresh_token",
+				"code id_token token": "authorization_code,implicit,refresh_token",
+			}[plan.Variant.ResponseType]
+
+			if strings.Join(client.GrantTypes, ",") != expected {
+				t.Errorf("%s: expected grant types '%s' but got %v", plan.Config.Alias, expected, client.GrantTypes)
+			}
+		}
 	}
+}
 
-	out, err := yaml.Marshal(&ClientData{IdentityProviders: ClientDataIdentityProviders{OpenIDConnect: ClientDataIdentityProvidersOpenIDConnect{Clients: clients}}})
+func TestPostPlanEncoding(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	plan, err := NewComprehensiveDiscoveryPlan("conformance-basic-code", "Comprehensive: Authorization Code Basic", "secret", "", "https://idp.example.com", "client_secret_basic", "code", "default", SummaryPublish)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
 	}
 
-	fmt.Println(string(out))
-}
+	response, err := suite.client().PostPlan(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-type ClientDataIdentityProviders struct {
-	OpenIDConnect ClientDataIdentityProvidersOpenIDConnect `yaml:"oidc"`
-}
+	if response.ID == "" || response.Name != "oidcc-test-plan" {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	request := suite.createRequests[0]
+
+	if request.query.Get("planName") != "oidcc-test-plan" {
+		t.Errorf("unexpected plan name '%s'", request.query.Get("planName"))
+	}
+
+	variant := map[string]string{}
+
+	if err = json.Unmarshal([]byte(request.query.Get("variant")), &variant); err != nil {
+		t.Fatal(err)
+	}
 
-type ClientDataIdentityProvidersOpenIDConnect struct {
-	Clients []Client `yaml:"clients"`
+	if len(variant) != 5 || variant["client_auth_type"] != "client_secret_basic" || variant["response_mode"] != "default" || variant["server_metadata"] != "discovery" {
+		t.Errorf("unexpected variant %v", variant)
+	}
+
+	config := map[string]any{}
+
+	if err = json.Unmarshal(request.body, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config["alias"] != "conformance-basic-code" || config["client"].(map[string]any)["client_id"] != "conformance-conformance-basic-code-1" {
+		t.Errorf("unexpected config %s", request.body)
+	}
+
+	if _, ok := config["client_secret_post"]; ok {
+		t.Errorf("expected empty clients to be omitted from the config %s", request.body)
+	}
 }
 
-type ClientData struct {
-	IdentityProviders ClientDataIdentityProviders `yaml:"identity_providers"`
+func TestPostPlanErrors(t *testing.T) {
+	suite := newFakeSuite(t)
+	client := suite.client()
+
+	suite.failCreate["broken"] = true
+
+	_, err := client.PostPlan(&PlanMetadata{Name: "oidcc-config-certification-test-plan", Config: &PlanConfig{Alias: "broken"}})
+	if err == nil || !strings.Contains(err.Error(), "plan could not be created") {
+		t.Errorf("expected the response body in the error but got %v", err)
+	}
+
+	responses, err := client.PostPlans(&PlanMetadata{Name: "oidcc-config-certification-test-plan", Config: &PlanConfig{Alias: "ok"}}, &PlanMetadata{Name: "oidcc-config-certification-test-plan", Config: &PlanConfig{Alias: "broken"}})
+	if err == nil || len(responses) != 1 {
+		t.Errorf("expected one response and an error but got %d responses and %v", len(responses), err)
+	}
+
+	if _, err = client.DeletePlan(PlanMetadata{}); err == nil {
+		t.Errorf("expected an error deleting a plan without an id")
+	}
+
+	if ok, err := client.DeletePlan(PlanMetadata{ID: "missing"}); err != nil || ok {
+		t.Errorf("expected deleting a missing plan to not be ok but got %t and %v", ok, err)
+	}
 }
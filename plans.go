@@ -1,11 +1,11 @@
 package oidcc
 
 import (
-	"fmt"
 	"net/url"
-	"strings"
 )
 
+const configPlanName = "oidcc-config-certification-test-plan"
+
 func NewPlansAll(issuer, secret string, publish Publish) (plans []*PlanMetadata, err error) {
 	var plan *PlanMetadata
 
@@ -55,16 +55,12 @@ func NewPlansAll(issuer, secret string, publish Publish) (plans []*PlanMetadata,
 }
 
 func NewPlanDiscovery(name string, variant *PlanVariant, publish Publish, alias, description, issuer string, client, client2, clientSecretPost *PlanClient) (plan *PlanMetadata, err error) {
-	var (
-		discoveryURI *url.URL
-	)
+	var discoveryURI *url.URL
 
-	if discoveryURI, err = url.ParseRequestURI(issuer); err != nil {
+	if discoveryURI, err = DiscoveryURL(issuer); err != nil {
 		return nil, err
 	}
 
-	discoveryURI = discoveryURI.JoinPath(".well-known", "openid-configuration")
-
 	plan = &PlanMetadata{
 		Name: name,
 		Config: &PlanConfig{
@@ -153,10 +149,10 @@ func NewCertificationProfileImplicitDiscoveryPlan(alias, description, secret, is
 }
 
 func NewCertificationProfileConfigDiscoveryPlan(alias, description, issuer string, publish Publish) (plan *PlanMetadata, err error) {
-	return NewPlanDiscovery("oidcc-config-certification-test-plan", nil, publish, alias, description, issuer, nil, nil, nil)
+	return NewPlanDiscovery(configPlanName, nil, publish, alias, description, issuer, nil, nil, nil)
 }
 
-var clientAuthTypes = []string{"none", "client_secret_basic", "client_secret_post", "client_secret_jwt"}
+var clientAuthTypes = []string{"none", "client_secret_basic", "client_secret_post", "client_secret_jwt", "private_key_jwt", "tls_client_auth", "self_signed_tls_client_auth"}
 var responseTypes = []string{"code", "id_token", "id_token token", "code id_token", "code token", "code id_token token"}
 var responseModes = []string{"default", "form_post"}
 
@@ -189,46 +185,140 @@ func clientAuthTypeToDescription(in string) string {
 		return "Post"
 	case "client_secret_jwt":
 		return "JWT"
+	case "private_key_jwt":
+		return "Private Key JWT"
+	case "tls_client_auth", "mtls":
+		return "mTLS"
+	case "self_signed_tls_client_auth":
+		return "Self-Signed mTLS"
 	default:
 		return ""
 	}
 }
 
-func NewComprehensiveDiscoveryPlanAll(secret, issuer string, publish Publish) (plans []*PlanMetadata, err error) {
-	for _, clientAuthType := range clientAuthTypes {
-		for _, responseType := range responseTypes {
-			for _, responseMode := range responseModes {
-				alias := fmt.Sprintf("conformance-%s-%s", strings.ReplaceAll(clientAuthType, "client_secret_", ""), strings.ReplaceAll(responseType, " ", "-"))
-				fp := ""
-				alg := ""
-
-				switch responseMode {
-				case "form_post":
-					alias += "formpost"
-					fp = " Form Post"
+// ComprehensiveMatrixOptions adjusts the matrix returned by NewComprehensiveVariantMatrixWithOptions.
+type ComprehensiveMatrixOptions struct {
+	// DynamicClients adds the client_registration dimension so every combination is also tested with clients the
+	// suite registers using dynamic client registration.
+	DynamicClients bool
+
+	// Registration is the registration config of the dynamic_client plans. Combinations its Policy does not allow are
+	// skipped.
+	Registration *PlanRegistration
+
+	// Discovery is the discovery document of the provider. If set the combinations using a client_auth_type,
+	// response_type, or response_mode it does not advertise are skipped, and reported by VariantMatrix.Skipped.
+	Discovery *DiscoveryDocument
+}
+
+// NewComprehensiveVariantMatrix returns the matrix of the comprehensive plans. The tls_client_auth plans share a
+// certificate authority which is generated when the first of them is built.
+func NewComprehensiveVariantMatrix(secret, issuer string, publish Publish) *VariantMatrix {
+	return NewComprehensiveVariantMatrixWithOptions(secret, issuer, publish, ComprehensiveMatrixOptions{})
+}
+
+// NewComprehensiveVariantMatrixWithOptions returns the matrix of the comprehensive plans like
+// NewComprehensiveVariantMatrix with the options applied.
+func NewComprehensiveVariantMatrixWithOptions(secret, issuer string, publish Publish, opts ComprehensiveMatrixOptions) *VariantMatrix {
+	var ca *CertificateAuthority
+
+	matrix := &VariantMatrix{
+		Dimensions: []VariantDimension{
+			{Name: "client_auth_type", Values: clientAuthTypes},
+			{Name: "response_type", Values: responseTypes},
+			{Name: "response_mode", Values: responseModes},
+		},
+		Alias:       `conformance-{{ replace .client_auth_type "client_secret_" "" }}-{{ replace .response_type " " "-" }}{{ if eq .response_mode "form_post" }}formpost{{ end }}`,
+		Description: `Comprehensive: {{ responseTypeDescription .response_type }} {{ clientAuthTypeDescription .client_auth_type }}{{ if eq .response_mode "form_post" }} Form Post{{ end }}`,
+		Build: func(alias, description string, combination VariantCombination) (plan *PlanMetadata, err error) {
+			clientAuthType := combination["client_auth_type"]
+
+			s, alg := secret, ""
+
+			variantClientAuthType := clientAuthType
+
+			switch clientAuthType {
+			case "none", "private_key_jwt":
+				s = ""
+			case "client_secret_jwt":
+				alg = "HS256"
+			case "tls_client_auth", "self_signed_tls_client_auth":
+				s, variantClientAuthType = "", "mtls"
+			}
+
+			if plan, err = NewComprehensiveDiscoveryPlan(alias, description, s, alg, issuer, variantClientAuthType, combination["response_type"], combination["response_mode"], publish); err != nil {
+				return nil, err
+			}
+
+			switch clientAuthType {
+			case "private_key_jwt":
+				for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2} {
+					if err = client.GenerateKey(DefaultClientKeyAlg); err != nil {
+						return nil, err
+					}
+				}
+			case "tls_client_auth":
+				if ca == nil {
+					if ca, err = NewCertificateAuthority("go-oidcc conformance client CA"); err != nil {
+						return nil, err
+					}
 				}
 
-				s := secret
-				switch clientAuthType {
-				case "none":
-					s = ""
-				case "client_secret_jwt":
-					alg = "HS256"
+				if plan.Config.MTLS, err = ca.IssueClientCertificate(plan.Config.Client.ClientID); err != nil {
+					return nil, err
 				}
 
-				plan, err := NewComprehensiveDiscoveryPlan(alias, fmt.Sprintf("Comprehensive: %s %s%s", responseTypeToFlowDescription(responseType), clientAuthTypeToDescription(clientAuthType), fp), s, alg, issuer, clientAuthType, responseType, responseMode, publish)
-				if err != nil {
+				if plan.Config.MTLS2, err = ca.IssueClientCertificate(plan.Config.Client2.ClientID); err != nil {
 					return nil, err
 				}
+			case "self_signed_tls_client_auth":
+				if plan.Config.MTLS, err = NewSelfSignedClientCertificate(plan.Config.Client.ClientID); err != nil {
+					return nil, err
+				}
+
+				if plan.Config.MTLS2, err = NewSelfSignedClientCertificate(plan.Config.Client2.ClientID); err != nil {
+					return nil, err
+				}
+			}
 
-				plan.Variant.ServerMetadata = ""
+			plan.Variant.ServerMetadata = ""
 
-				plans = append(plans, plan)
+			if combination["client_registration"] == "dynamic_client" {
+				plan.Variant.ClientRegistration = "dynamic_client"
+				plan.Config.Client, plan.Config.Client2 = nil, nil
+				plan.Config.Registration = opts.Registration
 			}
+
+			return plan, nil
+		},
+	}
+
+	if opts.Discovery != nil {
+		matrix.Constraints = append(matrix.Constraints, opts.Discovery.Constraints()...)
+	}
+
+	if opts.DynamicClients {
+		matrix.Dimensions = append(matrix.Dimensions, VariantDimension{Name: "client_registration", Values: []string{"static_client", "dynamic_client"}})
+		matrix.Alias += `{{ if eq .client_registration "dynamic_client" }}-dynamic{{ end }}`
+		matrix.Description += `{{ if eq .client_registration "dynamic_client" }} Dynamic{{ end }}`
+
+		if opts.Registration != nil && opts.Registration.Policy != nil {
+			policy := opts.Registration.Policy
+
+			matrix.Constraints = append(matrix.Constraints, VariantConstraint{
+				Name: "registration policy",
+				Allow: func(combination VariantCombination) bool {
+					return combination["client_registration"] != "dynamic_client" || policy.Allows(combination)
+				},
+			})
 		}
 	}
 
-	return plans, nil
+	return matrix
+}
+
+func NewComprehensiveDiscoveryPlanAll(secret, issuer string, publish Publish) (plans []*PlanMetadata, err error) {
+	return NewComprehensiveVariantMatrix(secret, issuer, publish).Plans()
 }
 
 func NewComprehensiveDiscoveryPlan(alias, description, secret, secretAlg, issuer, clientAuthType, responseType, responseMode string, publish Publish) (plan *PlanMetadata, err error) {
@@ -0,0 +1,492 @@
+package oidcc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+type PreflightSeverity int
+
+const (
+	PreflightWarning PreflightSeverity = iota
+	PreflightFatal
+)
+
+func (s PreflightSeverity) String() string {
+	switch s {
+	case PreflightWarning:
+		return "warning"
+	case PreflightFatal:
+		return "fatal"
+	default:
+		return ""
+	}
+}
+
+// PreflightFinding is a problem found by Preflight. The Plans are the aliases of the plans affected by the problem,
+// which are empty when the problem is with the issuer itself and affects every plan.
+type PreflightFinding struct {
+	Severity PreflightSeverity
+	Check    string
+	Message  string
+	Plans    []string
+}
+
+type PreflightReport struct {
+	Issuer    string
+	Discovery *DiscoveryDocument
+	JWKS      *JSONWebKeySet
+	Findings  []PreflightFinding
+}
+
+// Fatal reports whether any finding is fatal, in which case the plans should not be created.
+func (r *PreflightReport) Fatal() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == PreflightFatal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Err returns the fatal findings joined as an error, or nil if there are none.
+func (r *PreflightReport) Err() error {
+	var errs []error
+
+	for _, finding := range r.Findings {
+		if finding.Severity == PreflightFatal {
+			errs = append(errs, fmt.Errorf("preflight check '%s' failed: %s", finding.Check, finding.Message))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WriteTable writes the findings to w as a table.
+func (r *PreflightReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SEVERITY\tCHECK\tPLANS\tMESSAGE")
+
+	for _, finding := range r.Findings {
+		plans := "all"
+
+		if len(finding.Plans) != 0 {
+			plans = fmt.Sprintf("%d", len(finding.Plans))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", finding.Severity, finding.Check, plans, finding.Message)
+	}
+
+	return tw.Flush()
+}
+
+// add records a finding, merging it with an existing finding with the same severity, check, and message so a problem
+// shared by many plans is reported once.
+func (r *PreflightReport) add(severity PreflightSeverity, check, alias, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+
+	for i := range r.Findings {
+		finding := &r.Findings[i]
+
+		if finding.Severity != severity || finding.Check != check || finding.Message != message {
+			continue
+		}
+
+		if alias != "" && !slices.Contains(finding.Plans, alias) {
+			finding.Plans = append(finding.Plans, alias)
+		}
+
+		return
+	}
+
+	finding := PreflightFinding{Severity: severity, Check: check, Message: message}
+
+	if alias != "" {
+		finding.Plans = []string{alias}
+	}
+
+	r.Findings = append(r.Findings, finding)
+}
+
+// Preflight checks the issuer is able to run the plans before they're created. It fetches the discovery document of
+// the issuer and checks it with PreflightDocument. If the client is nil the http.DefaultClient is used.
+func Preflight(ctx context.Context, client *http.Client, issuer string, plans ...*PlanMetadata) *PreflightReport {
+	report := &PreflightReport{Issuer: issuer}
+
+	discoveryURI, err := DiscoveryURL(issuer)
+	if err != nil {
+		report.add(PreflightFatal, "discovery", "", "the issuer '%s' is not a valid url: %v", issuer, err)
+
+		return report
+	}
+
+	data, err := fetchJSON(ctx, client, discoveryURI.String(), "discovery document")
+	if err != nil {
+		report.add(PreflightFatal, "discovery", "", "%v", err)
+
+		return report
+	}
+
+	doc := &DiscoveryDocument{}
+
+	if err = json.Unmarshal(data, doc); err != nil {
+		report.add(PreflightFatal, "discovery", "", "error decoding discovery document: %v", err)
+
+		return report
+	}
+
+	return PreflightDocument(ctx, client, issuer, doc, plans...)
+}
+
+// PreflightDocument checks the issuer is able to run the plans using a discovery document which has already been
+// loaded, such as the document used for static server metadata. It checks the issuer matches, the required metadata
+// is present, and every endpoint uses https. It then checks the response types, response modes, token endpoint auth
+// methods, signing algorithms, and the PAR, DPoP, and logout support the clients of each plan require are advertised.
+// Finally it fetches the JWKS and checks the kid of every key is unique, the use and alg of every key match its type,
+// no private keys are published, and there is a signing key for the id_token signing algorithms. Problems which would
+// make the plans fail are fatal, the others are warnings. The relying party plans are ignored as the suite is their
+// OP. If the client is nil the http.DefaultClient is used.
+func PreflightDocument(ctx context.Context, client *http.Client, issuer string, doc *DiscoveryDocument, plans ...*PlanMetadata) *PreflightReport {
+	report := &PreflightReport{Issuer: issuer, Discovery: doc}
+
+	report.checkDiscovery()
+
+	// The id_token signing algorithms used by the plans, and the plans using each of them.
+	var (
+		algs    []string
+		aliases = map[string][]string{}
+	)
+
+	for _, plan := range plans {
+		if plan == nil || plan.IsRP() {
+			continue
+		}
+
+		alias := plan.Name
+
+		if plan.Config != nil && plan.Config.Alias != "" {
+			alias = plan.Config.Alias
+		}
+
+		for _, alg := range report.checkPlan(*plan, alias) {
+			if _, ok := aliases[alg]; !ok {
+				algs = append(algs, alg)
+			}
+
+			if !slices.Contains(aliases[alg], alias) {
+				aliases[alg] = append(aliases[alg], alias)
+			}
+		}
+	}
+
+	if doc.JSONWebKeysURI == "" {
+		return report
+	}
+
+	var err error
+
+	if report.JWKS, err = FetchJSONWebKeySet(ctx, client, doc.JSONWebKeysURI); err != nil {
+		report.add(PreflightFatal, "jwks", "", "%v", err)
+
+		return report
+	}
+
+	report.checkJWKS()
+
+	for _, alg := range algs {
+		if !hasSigningKey(report.JWKS, alg) {
+			for _, alias := range aliases[alg] {
+				report.add(PreflightFatal, "jwks", alias, "the jwks has no signing key for the alg '%s'", alg)
+			}
+		}
+	}
+
+	for _, alg := range doc.IDTokenSigningAlgValuesSupported {
+		if _, ok := aliases[alg]; !ok && !hasSigningKey(report.JWKS, alg) {
+			report.add(PreflightWarning, "jwks", "", "the jwks has no signing key for the alg '%s'", alg)
+		}
+	}
+
+	return report
+}
+
+func (r *PreflightReport) checkDiscovery() {
+	doc := r.Discovery
+
+	switch {
+	case doc.Issuer == "":
+		r.add(PreflightFatal, "required", "", "the discovery document has no 'issuer'")
+	case doc.Issuer != r.Issuer:
+		r.add(PreflightFatal, "issuer", "", "the discovery document issuer '%s' does not match the issuer '%s'", doc.Issuer, r.Issuer)
+	}
+
+	if u, err := url.Parse(r.Issuer); err == nil && (u.RawQuery != "" || u.Fragment != "") {
+		r.add(PreflightFatal, "issuer", "", "the issuer '%s' must not have a query or fragment", r.Issuer)
+	}
+
+	for _, field := range []struct {
+		name    string
+		missing bool
+	}{
+		{"authorization_endpoint", doc.AuthorizationEndpoint == ""},
+		{"token_endpoint", doc.TokenEndpoint == ""},
+		{"jwks_uri", doc.JSONWebKeysURI == ""},
+		{"response_types_supported", len(doc.ResponseTypesSupported) == 0},
+		{"subject_types_supported", len(doc.SubjectTypesSupported) == 0},
+		{"id_token_signing_alg_values_supported", len(doc.IDTokenSigningAlgValuesSupported) == 0},
+	} {
+		if field.missing {
+			r.add(PreflightFatal, "required", "", "the discovery document has no '%s'", field.name)
+		}
+	}
+
+	for _, field := range []struct {
+		name, value string
+	}{
+		{"issuer", doc.Issuer},
+		{"authorization_endpoint", doc.AuthorizationEndpoint},
+		{"token_endpoint", doc.TokenEndpoint},
+		{"userinfo_endpoint", doc.UserinfoEndpoint},
+		{"jwks_uri", doc.JSONWebKeysURI},
+		{"registration_endpoint", doc.RegistrationEndpoint},
+		{"end_session_endpoint", doc.EndSessionEndpoint},
+		{"pushed_authorization_request_endpoint", doc.PushedAuthorizationRequestEndpoint},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		if u, err := url.Parse(field.value); err != nil || u.Scheme != "https" || u.Host == "" {
+			r.add(PreflightFatal, "https", "", "the '%s' value '%s' is not an https url", field.name, field.value)
+		}
+	}
+}
+
+// checkPlan checks the discovery document advertises what the plan and its clients require, and returns the id_token
+// signing algorithms of the clients.
+func (r *PreflightReport) checkPlan(plan PlanMetadata, alias string) (algs []string) {
+	doc := r.Discovery
+
+	if plan.IsDynamic() && doc.RegistrationEndpoint == "" {
+		r.add(PreflightFatal, "registration", alias, "the discovery document has no 'registration_endpoint' which dynamic client registration requires")
+	}
+
+	for _, responseType := range plan.ResponseTypes() {
+		if !supportsResponseType(doc.ResponseTypesSupported, responseType) {
+			r.add(PreflightFatal, "response_type", alias, "the response type '%s' is not in the response_types_supported", responseType)
+		}
+	}
+
+	responseModesSupported := doc.responseModesSupported()
+
+	for _, responseMode := range planResponseModes(plan) {
+		if !slices.Contains(responseModesSupported, responseMode) {
+			r.add(PreflightFatal, "response_mode", alias, "the response mode '%s' is not in the response_modes_supported", responseMode)
+		}
+	}
+
+	clients, err := plan.GetClientsWithOptions(&url.URL{}, ClientOptions{})
+	if err != nil {
+		r.add(PreflightFatal, "clients", alias, "%v", err)
+
+		return nil
+	}
+
+	authMethodsSupported := doc.tokenEndpointAuthMethodsSupported()
+
+	for _, client := range clients {
+		if !slices.Contains(authMethodsSupported, client.TokenEndpointAuthMethod) {
+			r.add(PreflightFatal, "auth_method", alias, "the token endpoint auth method '%s' is not in the token_endpoint_auth_methods_supported", client.TokenEndpointAuthMethod)
+		}
+
+		if alg := client.TokenEndpointAuthSigningAlg; alg != "" && len(doc.TokenEndpointAuthSigningAlgValuesSupported) != 0 && !slices.Contains(doc.TokenEndpointAuthSigningAlgValuesSupported, alg) {
+			r.add(PreflightFatal, "auth_method", alias, "the token endpoint auth signing alg '%s' is not in the token_endpoint_auth_signing_alg_values_supported", alg)
+		}
+
+		if alg := client.IDTokenSignedResponseAlg; alg != "" {
+			if !slices.Contains(doc.IDTokenSigningAlgValuesSupported, alg) {
+				r.add(PreflightFatal, "signing_alg", alias, "the id_token signing alg '%s' is not in the id_token_signing_alg_values_supported", alg)
+			}
+
+			if alg != "none" && !slices.Contains(algs, alg) {
+				algs = append(algs, alg)
+			}
+		}
+
+		if alg := client.RequestObjectSigningAlg; alg != "" && alg != "none" && !slices.Contains(doc.RequestObjectSigningAlgValuesSupported, alg) {
+			r.add(PreflightFatal, "signing_alg", alias, "the request object signing alg '%s' is not in the request_object_signing_alg_values_supported", alg)
+		}
+
+		if method := client.PKCEChallengeMethod; method != "" && !slices.Contains(doc.CodeChallengeMethodsSupported, method) {
+			severity := PreflightFatal
+
+			if len(doc.CodeChallengeMethodsSupported) == 0 {
+				severity = PreflightWarning
+			}
+
+			r.add(severity, "pkce", alias, "the code challenge method '%s' is not in the code_challenge_methods_supported", method)
+		}
+
+		if client.RequirePushedAuthorizationRequests && doc.PushedAuthorizationRequestEndpoint == "" {
+			r.add(PreflightFatal, "par", alias, "the discovery document has no 'pushed_authorization_request_endpoint' which pushed authorization requests require")
+		}
+
+		if client.DPoPBoundAccessTokens && len(doc.DPoPSigningAlgValuesSupported) == 0 {
+			r.add(PreflightFatal, "dpop", alias, "the discovery document has no 'dpop_signing_alg_values_supported' which dpop bound access tokens require")
+		}
+
+		if len(client.PostLogoutRedirectURIs) != 0 && doc.EndSessionEndpoint == "" {
+			r.add(PreflightFatal, "logout", alias, "the discovery document has no 'end_session_endpoint' which rp-initiated logout requires")
+		}
+
+		if client.FrontChannelLogoutURI != "" && !doc.FrontChannelLogoutSupported {
+			r.add(PreflightFatal, "logout", alias, "the discovery document does not set 'frontchannel_logout_supported'")
+		}
+
+		if client.BackChannelLogoutURI != "" && !doc.BackChannelLogoutSupported {
+			r.add(PreflightFatal, "logout", alias, "the discovery document does not set 'backchannel_logout_supported'")
+		}
+	}
+
+	return algs
+}
+
+func (r *PreflightReport) checkJWKS() {
+	if len(r.JWKS.Keys) == 0 {
+		r.add(PreflightFatal, "jwks", "", "the jwks has no keys")
+
+		return
+	}
+
+	kids := map[string]int{}
+
+	for i, key := range r.JWKS.Keys {
+		name := fmt.Sprintf("key '%s'", key.KeyID)
+
+		if key.KeyID == "" {
+			name = fmt.Sprintf("key %d", i)
+
+			r.add(PreflightWarning, "jwks", "", "%s has no kid", name)
+		} else if kids[key.KeyID]++; kids[key.KeyID] == 2 {
+			r.add(PreflightFatal, "jwks", "", "the kid '%s' is used by more than one key", key.KeyID)
+		}
+
+		if key.D != "" {
+			r.add(PreflightFatal, "jwks", "", "%s has private key members", name)
+		}
+
+		switch key.KeyType {
+		case "RSA", "EC":
+			if _, err := key.PublicKey(); err != nil {
+				r.add(PreflightFatal, "jwks", "", "%s is invalid: %v", name, err)
+			}
+		default:
+			r.add(PreflightWarning, "jwks", "", "%s has the unsupported key type '%s'", name, key.KeyType)
+		}
+
+		switch key.Use {
+		case "", "sig", "enc":
+		default:
+			r.add(PreflightWarning, "jwks", "", "%s has the unknown use '%s'", name, key.Use)
+		}
+
+		if key.Algorithm == "" {
+			continue
+		}
+
+		kty, crv := jwkAlgorithmKeyType(key.Algorithm)
+
+		switch {
+		case kty == "":
+			r.add(PreflightWarning, "jwks", "", "%s has the unknown alg '%s'", name, key.Algorithm)
+		case kty != key.KeyType || (crv != "" && crv != key.Curve):
+			r.add(PreflightFatal, "jwks", "", "%s has the alg '%s' which can't be used with a %s key", name, key.Algorithm, strings.TrimSpace(key.KeyType+" "+key.Curve))
+		case key.Use == "enc":
+			r.add(PreflightFatal, "jwks", "", "%s has the signing alg '%s' but the use 'enc'", name, key.Algorithm)
+		}
+	}
+}
+
+// planResponseModes returns the response modes the plan uses in addition to the query and fragment defaults.
+func planResponseModes(plan PlanMetadata) (responseModes []string) {
+	if (plan.Variant != nil && plan.Variant.ResponseMode == "form_post") || strings.Contains(plan.Name, "formpost") {
+		responseModes = append(responseModes, "form_post")
+	}
+
+	if plan.Variant != nil && plan.Variant.FAPIResponseMode == "jarm" {
+		responseModes = append(responseModes, "jwt")
+	}
+
+	return responseModes
+}
+
+// supportsResponseType reports whether the response type is in the supported response types regardless of the order
+// of the space separated values.
+func supportsResponseType(supported []string, responseType string) bool {
+	values := strings.Fields(responseType)
+
+	slices.Sort(values)
+
+	for _, s := range supported {
+		fields := strings.Fields(s)
+
+		slices.Sort(fields)
+
+		if slices.Equal(values, fields) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jwkAlgorithmKeyType returns the key type and for EC the curve of the keys which can be used with the algorithm, or
+// an empty key type if the algorithm is unknown.
+func jwkAlgorithmKeyType(alg string) (kty, crv string) {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		return "RSA", ""
+	case "ES256":
+		return "EC", "P-256"
+	case "ES384":
+		return "EC", "P-384"
+	case "ES512":
+		return "EC", "P-521"
+	case "EdDSA":
+		return "OKP", ""
+	default:
+		return "", ""
+	}
+}
+
+// hasSigningKey reports whether the set has a key which can sign with the algorithm. A key without an alg can be used
+// with any algorithm matching its type.
+func hasSigningKey(set *JSONWebKeySet, alg string) bool {
+	kty, crv := jwkAlgorithmKeyType(alg)
+
+	for _, key := range set.Keys {
+		if key.Use == "enc" {
+			continue
+		}
+
+		if key.Algorithm != "" && key.Algorithm != alg {
+			continue
+		}
+
+		if kty == "" || (key.KeyType == kty && (crv == "" || key.Curve == crv)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,252 @@
+package oidcc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newPreflightIssuer starts an issuer which serves a discovery document and JWKS supporting the certification profile
+// plans, after the modify func has been applied to them.
+func newPreflightIssuer(t *testing.T, modify func(doc *DiscoveryDocument, jwks *JSONWebKeySet)) *httptest.Server {
+	t.Helper()
+
+	key, err := GenerateJSONWebKey("RS256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc, jwks []byte
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_, _ = w.Write(doc)
+		case "/jwks.json":
+			_, _ = w.Write(jwks)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	d := &DiscoveryDocument{
+		Issuer:                            server.URL,
+		AuthorizationEndpoint:             server.URL + "/authorization",
+		TokenEndpoint:                     server.URL + "/token",
+		UserinfoEndpoint:                  server.URL + "/userinfo",
+		JSONWebKeysURI:                    server.URL + "/jwks.json",
+		ResponseTypesSupported:            []string{"code", "id_token", "id_token token", "code id_token", "code token", "code id_token token"},
+		ResponseModesSupported:            []string{"query", "fragment", "form_post"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post"},
+	}
+
+	set := &JSONWebKeySet{Keys: []JSONWebKey{key.Public()}}
+
+	if modify != nil {
+		modify(d, set)
+	}
+
+	if doc, err = json.Marshal(d); err != nil {
+		t.Fatal(err)
+	}
+
+	if jwks, err = json.Marshal(set); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func preflightMessages(report *PreflightReport, severity PreflightSeverity) (messages []string) {
+	for _, finding := range report.Findings {
+		if finding.Severity == severity {
+			messages = append(messages, finding.Message)
+		}
+	}
+
+	return messages
+}
+
+func TestPreflight(t *testing.T) {
+	server := newPreflightIssuer(t, nil)
+
+	plans, err := NewPlansAll(server.URL, "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Preflight(context.Background(), server.Client(), server.URL, plans...)
+
+	if len(report.Findings) != 0 || report.Fatal() || report.Err() != nil {
+		t.Fatalf("expected no findings but got %+v", report.Findings)
+	}
+
+	if report.Discovery == nil || report.Discovery.Issuer != server.URL || report.JWKS == nil || len(report.JWKS.Keys) != 1 {
+		t.Errorf("expected the report to have the discovery document and jwks but got %+v", report)
+	}
+}
+
+func TestPreflightDiscovery(t *testing.T) {
+	server := newPreflightIssuer(t, func(doc *DiscoveryDocument, _ *JSONWebKeySet) {
+		doc.Issuer = "https://idp.example.com"
+		doc.UserinfoEndpoint = "http://idp.example.com/userinfo"
+		doc.SubjectTypesSupported = nil
+		doc.ResponseModesSupported = nil
+		doc.ResponseTypesSupported = []string{"code", "id_token"}
+	})
+
+	plans, err := NewPlansAll(server.URL, "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Preflight(context.Background(), server.Client(), server.URL, plans...)
+
+	if !report.Fatal() || report.Err() == nil {
+		t.Fatalf("expected fatal findings but got %+v", report.Findings)
+	}
+
+	messages := preflightMessages(report, PreflightFatal)
+
+	for _, expected := range []string{
+		"the discovery document issuer 'https://idp.example.com' does not match the issuer '" + server.URL + "'",
+		"the discovery document has no 'subject_types_supported'",
+		"the 'userinfo_endpoint' value 'http://idp.example.com/userinfo' is not an https url",
+		"the response type 'code id_token' is not in the response_types_supported",
+		"the response mode 'form_post' is not in the response_modes_supported",
+	} {
+		if !slices.Contains(messages, expected) {
+			t.Errorf("expected the fatal finding %q but got %q", expected, messages)
+		}
+	}
+
+	for _, finding := range report.Findings {
+		if finding.Message != "the response mode 'form_post' is not in the response_modes_supported" {
+			continue
+		}
+
+		if !slices.Equal(finding.Plans, []string{"certification-profile-formpost-basic", "certification-profile-formpost-hybrid", "certification-profile-formpost-implicit"}) {
+			t.Errorf("expected the finding to be merged for the form post plans but got %q", finding.Plans)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	if err = report.WriteTable(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "SEVERITY") || !strings.Contains(buf.String(), "response_mode") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
+
+func TestPreflightPlans(t *testing.T) {
+	server := newPreflightIssuer(t, nil)
+
+	logout, err := NewLogoutPlansAll(server.URL, "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dynamic, err := NewCertificationProfileDynamicDiscoveryPlan("dynamic", "Dynamic", server.URL, nil, NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Preflight(context.Background(), server.Client(), server.URL, append(logout, dynamic)...)
+
+	messages := preflightMessages(report, PreflightFatal)
+
+	for _, expected := range []string{
+		"the discovery document has no 'end_session_endpoint' which rp-initiated logout requires",
+		"the discovery document does not set 'frontchannel_logout_supported'",
+		"the discovery document does not set 'backchannel_logout_supported'",
+		"the discovery document has no 'registration_endpoint' which dynamic client registration requires",
+	} {
+		if !slices.Contains(messages, expected) {
+			t.Errorf("expected the fatal finding %q but got %q", expected, messages)
+		}
+	}
+}
+
+func TestPreflightJWKS(t *testing.T) {
+	ec, err := GenerateJSONWebKey("ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := newPreflightIssuer(t, func(_ *DiscoveryDocument, jwks *JSONWebKeySet) {
+		mismatched := ec.Public()
+		mismatched.Algorithm = "RS256"
+
+		anonymous := ec.Public()
+		anonymous.KeyID, anonymous.Algorithm = "", ""
+
+		jwks.Keys = []JSONWebKey{ec, mismatched, anonymous}
+	})
+
+	plans, err := NewPlansAll(server.URL, "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Preflight(context.Background(), server.Client(), server.URL, plans...)
+
+	for _, expected := range []string{
+		"the kid '" + ec.KeyID + "' is used by more than one key",
+		"key '" + ec.KeyID + "' has private key members",
+		"key '" + ec.KeyID + "' has the alg 'RS256' which can't be used with a EC P-256 key",
+		"the jwks has no signing key for the alg 'RS256'",
+	} {
+		if !slices.Contains(preflightMessages(report, PreflightFatal), expected) {
+			t.Errorf("expected the fatal finding %q but got %+v", expected, report.Findings)
+		}
+	}
+
+	if !slices.Contains(preflightMessages(report, PreflightWarning), "key 2 has no kid") {
+		t.Errorf("expected a warning for the key without a kid but got %+v", report.Findings)
+	}
+}
+
+func TestPreflightUnreachable(t *testing.T) {
+	server := newPreflightIssuer(t, nil)
+
+	report := Preflight(context.Background(), server.Client(), server.URL+"/missing")
+
+	if !report.Fatal() || len(report.Findings) != 1 || report.Findings[0].Check != "discovery" || !strings.Contains(report.Findings[0].Message, "failed with status 404") {
+		t.Errorf("expected a fatal discovery finding but got %+v", report.Findings)
+	}
+}
+
+func TestPreflightDocument(t *testing.T) {
+	server := newPreflightIssuer(t, nil)
+
+	doc, err := FetchDiscoveryDocument(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The issuer has no discovery document, as when discovery is disabled and static server metadata is used.
+	doc.Issuer = "https://idp.invalid"
+
+	plans, err := NewPlansAll(doc.Issuer, "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := PreflightDocument(context.Background(), server.Client(), doc.Issuer, doc, plans...)
+
+	if len(report.Findings) != 0 || report.Discovery != doc || report.JWKS == nil {
+		t.Errorf("expected the document to be checked without fetching discovery but got %+v", report.Findings)
+	}
+}
@@ -0,0 +1,133 @@
+package oidcc
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	rpBasicPlanName            = "oidcc-client-basic-certification-test-plan"
+	rpImplicitPlanName         = "oidcc-client-implicit-certification-test-plan"
+	rpHybridPlanName           = "oidcc-client-hybrid-certification-test-plan"
+	rpConfigPlanName           = "oidcc-client-config-certification-test-plan"
+	rpDynamicPlanName          = "oidcc-client-dynamic-certification-test-plan"
+	rpFormPostBasicPlanName    = "oidcc-client-formpost-basic-certification-test-plan"
+	rpFormPostImplicitPlanName = "oidcc-client-formpost-implicit-certification-test-plan"
+	rpFormPostHybridPlanName   = "oidcc-client-formpost-hybrid-certification-test-plan"
+)
+
+// IsRP reports whether the plan is one of the oidcc-client plans which test a relying party with the suite acting as
+// the OP.
+func (p PlanMetadata) IsRP() bool {
+	return strings.HasPrefix(p.Name, "oidcc-client-")
+}
+
+// NewPlanRP builds an oidcc-client plan which tests a relying party. The suite acts as the OP for the relying party so
+// the plan has no server config, and the client is the static client the relying party uses with the client_id and
+// redirect_uri the suite accepts. The client is nil when the variant uses dynamic client registration.
+func NewPlanRP(name string, variant *PlanVariant, publish Publish, alias, description string, client *PlanClient) (plan *PlanMetadata, err error) {
+	if variant == nil || variant.ClientRegistration != "dynamic_client" {
+		if client == nil || client.ClientID == "" || client.RedirectURI == "" {
+			return nil, fmt.Errorf("plan '%s': the client id and redirect uri of the relying party are required by static client plans", alias)
+		}
+	}
+
+	plan = &PlanMetadata{
+		Name: name,
+		Config: &PlanConfig{
+			Alias:       alias,
+			Description: description,
+			Client:      client,
+		},
+		Publish: publish.String(),
+		Variant: variant,
+	}
+
+	return plan, nil
+}
+
+func newClientCertificationProfilePlan(name, alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	variant := &PlanVariant{
+		ClientRegistration: "static_client",
+		ClientAuthType:     clientAuthType,
+	}
+
+	if client != nil {
+		c := *client
+		client = &c
+	}
+
+	return NewPlanRP(name, variant, publish, alias, description, client)
+}
+
+func NewClientCertificationProfileBasicPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpBasicPlanName, alias, description, clientAuthType, client, publish)
+}
+
+func NewClientCertificationProfileImplicitPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpImplicitPlanName, alias, description, clientAuthType, client, publish)
+}
+
+func NewClientCertificationProfileHybridPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpHybridPlanName, alias, description, clientAuthType, client, publish)
+}
+
+func NewClientCertificationProfileConfigPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpConfigPlanName, alias, description, clientAuthType, client, publish)
+}
+
+func NewClientCertificationProfileFormPostBasicPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpFormPostBasicPlanName, alias, description, clientAuthType, client, publish)
+}
+
+func NewClientCertificationProfileFormPostImplicitPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpFormPostImplicitPlanName, alias, description, clientAuthType, client, publish)
+}
+
+func NewClientCertificationProfileFormPostHybridPlan(alias, description, clientAuthType string, client *PlanClient, publish Publish) (plan *PlanMetadata, err error) {
+	return newClientCertificationProfilePlan(rpFormPostHybridPlanName, alias, description, clientAuthType, client, publish)
+}
+
+// NewClientCertificationProfileDynamicPlan builds the Dynamic RP certification profile plan. The relying party
+// registers itself with the suite so the plan has no static client.
+func NewClientCertificationProfileDynamicPlan(alias, description, clientAuthType string, publish Publish) (plan *PlanMetadata, err error) {
+	variant := &PlanVariant{
+		ClientRegistration: "dynamic_client",
+		ClientAuthType:     clientAuthType,
+	}
+
+	return NewPlanRP(rpDynamicPlanName, variant, publish, alias, description, nil)
+}
+
+// NewClientPlansAll builds every RP certification profile plan for a relying party which uses the client and client
+// authentication type. Each static client plan gets its own copy of the client.
+func NewClientPlansAll(clientAuthType string, client *PlanClient, publish Publish) (plans []*PlanMetadata, err error) {
+	for _, builder := range []struct {
+		build              func(alias, description, clientAuthType string, client *PlanClient, publish Publish) (*PlanMetadata, error)
+		alias, description string
+	}{
+		{NewClientCertificationProfileBasicPlan, "client-certification-profile-basic", "Client Certification Profile: Basic"},
+		{NewClientCertificationProfileImplicitPlan, "client-certification-profile-implicit", "Client Certification Profile: Implicit"},
+		{NewClientCertificationProfileHybridPlan, "client-certification-profile-hybrid", "Client Certification Profile: Hybrid"},
+		{NewClientCertificationProfileConfigPlan, "client-certification-profile-config", "Client Certification Profile: Config"},
+		{NewClientCertificationProfileFormPostBasicPlan, "client-certification-profile-formpost-basic", "Client Certification Profile: Form Post Basic"},
+		{NewClientCertificationProfileFormPostImplicitPlan, "client-certification-profile-formpost-implicit", "Client Certification Profile: Form Post Implicit"},
+		{NewClientCertificationProfileFormPostHybridPlan, "client-certification-profile-formpost-hybrid", "Client Certification Profile: Form Post Hybrid"},
+	} {
+		var plan *PlanMetadata
+
+		if plan, err = builder.build(builder.alias, builder.description, clientAuthType, client, publish); err != nil {
+			return nil, err
+		}
+
+		plans = append(plans, plan)
+	}
+
+	var plan *PlanMetadata
+
+	if plan, err = NewClientCertificationProfileDynamicPlan("client-certification-profile-dynamic", "Client Certification Profile: Dynamic", clientAuthType, publish); err != nil {
+		return nil, err
+	}
+
+	return append(plans, plan), nil
+}
@@ -0,0 +1,51 @@
+package oidcc
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewClientPlansAll(t *testing.T) {
+	client := &PlanClient{ClientID: "rp", ClientSecret: "secret", RedirectURI: "https://rp.example.com/callback"}
+
+	plans, err := NewClientPlansAll("client_secret_basic", client, SummaryPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plans) != 8 {
+		t.Fatalf("expected 8 plans but got %d", len(plans))
+	}
+
+	root, _ := url.Parse("https://suite.example.com")
+
+	for _, plan := range plans {
+		if !plan.IsRP() || !strings.HasPrefix(plan.Name, "oidcc-client-") {
+			t.Errorf("plan '%s': expected a relying party plan", plan.Name)
+		}
+
+		if plan.Config.Server != nil || plan.Variant.ClientAuthType != "client_secret_basic" {
+			t.Errorf("plan '%s': unexpected config %+v and variant %+v", plan.Name, plan.Config, plan.Variant)
+		}
+
+		if clients := plan.GetClients(root); clients != nil {
+			t.Errorf("plan '%s': expected no identity provider clients but got %d", plan.Name, len(clients))
+		}
+
+		switch plan.Variant.ClientRegistration {
+		case "dynamic_client":
+			if plan.Name != "oidcc-client-dynamic-certification-test-plan" || plan.Config.Client != nil {
+				t.Errorf("plan '%s': expected the dynamic plan to have no client", plan.Name)
+			}
+		default:
+			if plan.Config.Client == nil || plan.Config.Client == client || *plan.Config.Client != *client {
+				t.Errorf("plan '%s': expected a copy of the client", plan.Name)
+			}
+		}
+	}
+
+	if _, err = NewClientCertificationProfileBasicPlan("rp-basic", "RP Basic", "client_secret_basic", &PlanClient{ClientID: "rp"}, SummaryPublish); err == nil || !strings.Contains(err.Error(), "redirect uri") {
+		t.Errorf("expected an error for a client without a redirect uri but got %v", err)
+	}
+}
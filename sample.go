@@ -0,0 +1,151 @@
+package oidcc
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Sample returns a covering array of the matrix where every allowed combination of values across any strength
+// dimensions appears in at least one of the returned combinations, i.e. a strength of 2 produces pairwise coverage.
+// The result is deterministic for a given seed. A strength of zero or a strength greater than or equal to the number
+// of dimensions returns the full matrix. The Include cases are always appended.
+func (m *VariantMatrix) Sample(strength int, seed int64) (combinations []VariantCombination, err error) {
+	if strength < 0 {
+		return nil, fmt.Errorf("sample strength must not be negative but was %d", strength)
+	}
+
+	if strength == 0 || strength >= len(m.Dimensions) {
+		return m.Combinations(), nil
+	}
+
+	var candidates []VariantCombination
+
+	m.walk(0, VariantCombination{}, func(combination VariantCombination) {
+		if m.Allowed(combination) {
+			candidates = append(candidates, combination)
+		}
+	})
+
+	random := rand.New(rand.NewSource(seed))
+
+	random.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	subsets := dimensionSubsets(len(m.Dimensions), strength)
+
+	tuples := make([][]string, len(candidates))
+	uncovered := map[string]bool{}
+
+	for i, candidate := range candidates {
+		tuples[i] = m.tuples(candidate, subsets)
+
+		for _, tuple := range tuples[i] {
+			uncovered[tuple] = true
+		}
+	}
+
+	selected := make([]bool, len(candidates))
+	seen := map[string]bool{}
+
+	for len(uncovered) != 0 {
+		best, bestCount := -1, 0
+
+		for i := range candidates {
+			if selected[i] {
+				continue
+			}
+
+			count := 0
+
+			for _, tuple := range tuples[i] {
+				if uncovered[tuple] {
+					count++
+				}
+			}
+
+			if count > bestCount {
+				best, bestCount = i, count
+			}
+		}
+
+		if best == -1 {
+			break
+		}
+
+		selected[best] = true
+
+		for _, tuple := range tuples[best] {
+			delete(uncovered, tuple)
+		}
+
+		seen[candidates[best].String()] = true
+
+		combinations = append(combinations, candidates[best])
+	}
+
+	for _, include := range m.Include {
+		if seen[include.String()] {
+			continue
+		}
+
+		seen[include.String()] = true
+
+		combinations = append(combinations, include)
+	}
+
+	return combinations, nil
+}
+
+func (m *VariantMatrix) SamplePlans(strength int, seed int64) (plans []*PlanMetadata, err error) {
+	var combinations []VariantCombination
+
+	if combinations, err = m.Sample(strength, seed); err != nil {
+		return nil, err
+	}
+
+	return m.PlansFor(combinations)
+}
+
+func (m *VariantMatrix) tuples(combination VariantCombination, subsets [][]int) (tuples []string) {
+	for _, subset := range subsets {
+		parts := make([]string, len(subset))
+
+		for i, d := range subset {
+			name := m.Dimensions[d].Name
+
+			parts[i] = name + "=" + combination[name]
+		}
+
+		tuples = append(tuples, strings.Join(parts, ","))
+	}
+
+	return tuples
+}
+
+func dimensionSubsets(n, k int) (subsets [][]int) {
+	subset := make([]int, 0, k)
+
+	var walk func(start int)
+
+	walk = func(start int) {
+		if len(subset) == k {
+			subsets = append(subsets, append([]int(nil), subset...))
+
+			return
+		}
+
+		for i := start; i < n; i++ {
+			subset = append(subset, i)
+
+			walk(i + 1)
+
+			subset = subset[:len(subset)-1]
+		}
+	}
+
+	walk(0)
+
+	return subsets
+}
@@ -0,0 +1,110 @@
+package oidcc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// DefaultPBKDF2Iterations is the number of PBKDF2-SHA512 iterations used by HashClientSecret when no iterations are
+	// specified. It matches the Authelia default.
+	DefaultPBKDF2Iterations = 310000
+
+	pbkdf2SaltLength = 16
+	pbkdf2KeyLength  = 64
+
+	clientSecretInfo = "go-oidcc client secret "
+)
+
+// ab64 is the adapted base64 encoding of the modular crypt format digests Authelia reads.
+var ab64 = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789./").WithPadding(base64.NoPadding)
+
+// HashClientSecret returns the PBKDF2-SHA512 digest of the secret with a random salt in the format Authelia accepts
+// for the client_secret option, i.e. $pbkdf2-sha512$<iterations>$<salt>$<key>. If iterations is zero the
+// DefaultPBKDF2Iterations are used.
+func HashClientSecret(secret string, iterations int) (digest string, err error) {
+	if iterations == 0 {
+		iterations = DefaultPBKDF2Iterations
+	}
+
+	if iterations < 0 {
+		return "", fmt.Errorf("error hashing client secret: iterations must be positive but it's %d", iterations)
+	}
+
+	salt := make([]byte, pbkdf2SaltLength)
+
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return "", fmt.Errorf("error hashing client secret: %w", err)
+	}
+
+	key := pbkdf2.Key([]byte(secret), salt, iterations, pbkdf2KeyLength, sha512.New)
+
+	return "$pbkdf2-sha512$" + strconv.Itoa(iterations) + "$" + ab64.EncodeToString(salt) + "$" + ab64.EncodeToString(key), nil
+}
+
+// VerifyClientSecret reports whether the secret matches a digest returned by HashClientSecret or a $plaintext$ value.
+func VerifyClientSecret(digest, secret string) (bool, error) {
+	if value, ok := strings.CutPrefix(digest, "$plaintext$"); ok {
+		return hmac.Equal([]byte(value), []byte(secret)), nil
+	}
+
+	parts := strings.Split(digest, "$")
+
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "pbkdf2-sha512" {
+		return false, fmt.Errorf("error verifying client secret: the digest is not a pbkdf2-sha512 digest")
+	}
+
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations <= 0 {
+		return false, fmt.Errorf("error verifying client secret: the digest has invalid iterations '%s'", parts[2])
+	}
+
+	salt, err := ab64.DecodeString(parts[3])
+	if err != nil {
+		return false, fmt.Errorf("error verifying client secret: the digest has an invalid salt: %w", err)
+	}
+
+	key, err := ab64.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("error verifying client secret: the digest has an invalid key: %w", err)
+	}
+
+	return hmac.Equal(key, pbkdf2.Key([]byte(secret), salt, iterations, len(key), sha512.New)), nil
+}
+
+// DeriveClientSecret derives the secret of a client from a master secret using HKDF-SHA256 with the client ID as
+// context, so each client has a distinct secret and a leaked secret does not expose the secret of any other client.
+func DeriveClientSecret(master, clientID string) string {
+	key := make([]byte, 32)
+
+	// The HKDF reader only fails after 255 blocks of output.
+	_, _ = io.ReadFull(hkdf.New(sha256.New, []byte(master), nil, []byte(clientSecretInfo+clientID)), key)
+
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// DeriveClientSecrets replaces the secret of every client of the plans with the secret derived from the master secret
+// and the client ID using DeriveClientSecret.
+func DeriveClientSecrets(master string, plans ...*PlanMetadata) {
+	for _, plan := range plans {
+		if plan == nil || plan.Config == nil {
+			continue
+		}
+
+		for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2, plan.Config.ClientSecretPost} {
+			if client != nil && client.ClientSecret != "" {
+				client.ClientSecret = DeriveClientSecret(master, client.ClientID)
+			}
+		}
+	}
+}
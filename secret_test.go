@@ -0,0 +1,186 @@
+package oidcc
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestVerifyClientSecretKnownAnswer(t *testing.T) {
+	key, _ := hex.DecodeString("867f70cf1ade02cff3752599a3a53dc4af34c7a669815ae5d513554e1c8cf252c02d470a285a0501bad999bfe943c08f050235d7d68b1da55e63f73b60a57fce")
+
+	digest := "$pbkdf2-sha512$1$" + ab64.EncodeToString([]byte("salt")) + "$" + ab64.EncodeToString(key)
+
+	if ok, err := VerifyClientSecret(digest, "password"); !ok || err != nil {
+		t.Errorf("expected the PBKDF2-SHA512 test vector to verify but got %t %v", ok, err)
+	}
+}
+
+func TestDeriveClientSecretKnownAnswer(t *testing.T) {
+	if secret := DeriveClientSecret("master", "conformance-basic-1"); secret != "OSy1sGIArgt0F_7SDKA9WdPruQJV5mtiko4XEBAJMVI" {
+		t.Errorf("unexpected derived secret '%s'", secret)
+	}
+}
+
+func TestHashClientSecret(t *testing.T) {
+	digest, err := HashClientSecret("secret", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(digest, "$")
+
+	if len(parts) != 5 || parts[1] != "pbkdf2-sha512" || parts[2] != "1000" || len(parts[3]) != 22 || len(parts[4]) != 86 {
+		t.Fatalf("unexpected digest '%s'", digest)
+	}
+
+	if strings.ContainsAny(parts[3]+parts[4], "+=") {
+		t.Errorf("expected the adapted base64 encoding but got '%s'", digest)
+	}
+
+	for secret, expected := range map[string]bool{"secret": true, "Secret": false, "": false} {
+		if ok, err := VerifyClientSecret(digest, secret); err != nil || ok != expected {
+			t.Errorf("verifying '%s': expected %t but got %t %v", secret, expected, ok, err)
+		}
+	}
+
+	if ok, err := VerifyClientSecret("$plaintext$secret", "secret"); !ok || err != nil {
+		t.Errorf("expected the plaintext secret to verify but got %t %v", ok, err)
+	}
+
+	if _, err = VerifyClientSecret("$argon2id$v=19$m=65536,t=3,p=4$abc$def", "secret"); err == nil {
+		t.Errorf("expected an error verifying an unsupported digest")
+	}
+
+	if _, err = HashClientSecret("secret", -1); err == nil {
+		t.Errorf("expected an error for negative iterations")
+	}
+}
+
+func TestDeriveClientSecrets(t *testing.T) {
+	plans, err := NewPlansAll("https://idp.example.com", "secret", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	DeriveClientSecrets("master", plans...)
+
+	seen := map[string]string{}
+
+	for _, plan := range plans {
+		for _, client := range []*PlanClient{plan.Config.Client, plan.Config.Client2, plan.Config.ClientSecretPost} {
+			if client == nil {
+				continue
+			}
+
+			if client.ClientSecret != DeriveClientSecret("master", client.ClientID) || len(client.ClientSecret) != 43 {
+				t.Errorf("client '%s': unexpected secret '%s'", client.ClientID, client.ClientSecret)
+			}
+
+			if other, ok := seen[client.ClientSecret]; ok {
+				t.Errorf("client '%s': has the same secret as client '%s'", client.ClientID, other)
+			}
+
+			seen[client.ClientSecret] = client.ClientID
+		}
+	}
+
+	if DeriveClientSecret("master", "a") == DeriveClientSecret("other", "a") {
+		t.Errorf("expected the derived secret to depend on the master secret")
+	}
+}
+
+func TestGetClientsWithOptionsHashSecrets(t *testing.T) {
+	root, _ := url.Parse("https://suite.example.com")
+
+	for _, clientAuthType := range []string{"client_secret_basic", "client_secret_jwt", "none"} {
+		plan, err := NewComprehensiveDiscoveryPlan("alias", "description", "secret", "", "https://idp.example.com", clientAuthType, "code", "default", NoPublish)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clients, err := plan.GetClientsWithOptions(root, ClientOptions{HashSecrets: true, HashIterations: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = ValidateClients(clients); err != nil {
+			t.Error(err)
+		}
+
+		for _, client := range clients {
+			if plan.Config.Client.ClientSecret != "secret" {
+				t.Errorf("%s: expected the plan to keep the plaintext secret", clientAuthType)
+			}
+
+			switch {
+			case client.Public:
+				if client.ClientSecret != "" {
+					t.Errorf("%s: expected no secret but got '%s'", clientAuthType, client.ClientSecret)
+				}
+			case client.TokenEndpointAuthMethod == "client_secret_jwt":
+				if client.ClientSecret != "$plaintext$secret" {
+					t.Errorf("%s: expected the plaintext secret but got '%s'", clientAuthType, client.ClientSecret)
+				}
+			default:
+				if ok, err := VerifyClientSecret(client.ClientSecret, "secret"); !ok || err != nil || !strings.HasPrefix(client.ClientSecret, "$pbkdf2-sha512$10$") {
+					t.Errorf("%s: unexpected digest '%s'", clientAuthType, client.ClientSecret)
+				}
+			}
+		}
+	}
+}
+
+func TestGetClientsWithOptionsSecretDigests(t *testing.T) {
+	root, _ := url.Parse("https://suite.example.com")
+
+	plan, err := NewComprehensiveDiscoveryPlan("alias", "description", "secret", "", "https://idp.example.com", "client_secret_basic", "code", "default", NoPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ClientOptions{HashSecrets: true, HashIterations: 10}
+
+	first, err := plan.GetClientsWithOptions(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MergeClients(nil, first, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.SecretDigests, err = ClientSecretDigests(data); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := plan.GetClientsWithOptions(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range first {
+		if second[i].ClientSecret != first[i].ClientSecret {
+			t.Errorf("%s: expected the existing digest to be kept but got '%s'", first[i].ClientID, second[i].ClientSecret)
+		}
+	}
+
+	plan.Config.Client.ClientSecret = "rotated"
+
+	opts.HashIterations = 20
+
+	third, err := plan.GetClientsWithOptions(root, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, _ := VerifyClientSecret(third[0].ClientSecret, "rotated"); !ok || third[0].ClientSecret == first[0].ClientSecret {
+		t.Errorf("expected a new digest for the changed secret but got '%s'", third[0].ClientSecret)
+	}
+
+	if !strings.HasPrefix(third[1].ClientSecret, "$pbkdf2-sha512$20$") {
+		t.Errorf("expected a new digest for the changed iterations but got '%s'", third[1].ClientSecret)
+	}
+}
@@ -0,0 +1,305 @@
+package oidcc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+type SyncAction int
+
+const (
+	SyncCreate SyncAction = iota
+	SyncUnchanged
+	SyncRecreate
+	SyncOrphan
+	SyncDeleteOrphan
+)
+
+func (a SyncAction) String() string {
+	switch a {
+	case SyncCreate:
+		return "create"
+	case SyncUnchanged:
+		return "unchanged"
+	case SyncRecreate:
+		return "recreate"
+	case SyncOrphan:
+		return "orphan"
+	case SyncDeleteOrphan:
+		return "delete"
+	default:
+		return ""
+	}
+}
+
+type SyncOptions struct {
+	// DryRun computes the changes without creating, archiving, or deleting any plans.
+	DryRun bool
+
+	// DeleteOrphans deletes existing plans which do not match a desired plan instead of only reporting them.
+	DeleteOrphans bool
+
+	// Search limits the existing plans considered by the sync to those matching the suite search.
+	Search string
+
+	// Archive is called with each existing plan before it is deleted so its ID and metadata can be retained.
+	Archive func(plan PlanMetadata) error
+}
+
+type SyncChange struct {
+	Action      SyncAction
+	Alias       string
+	Description string
+	ExistingID  string
+	CreatedID   string
+	Desired     *PlanMetadata
+	Existing    *PlanMetadata
+	Err         error
+}
+
+type SyncResult struct {
+	DryRun  bool
+	Changes []SyncChange
+}
+
+func (r *SyncResult) Count(action SyncAction) (count int) {
+	for _, change := range r.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (r *SyncResult) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if r.DryRun {
+		fmt.Fprintln(tw, "DRY RUN: no changes have been made")
+	}
+
+	fmt.Fprintln(tw, "ACTION\tALIAS\tDESCRIPTION\tEXISTING ID\tCREATED ID\tERROR")
+
+	for _, change := range r.Changes {
+		errMsg := ""
+
+		if change.Err != nil {
+			errMsg = change.Err.Error()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", change.Action, change.Alias, change.Description, change.ExistingID, change.CreatedID, errMsg)
+	}
+
+	return tw.Flush()
+}
+
+// ArchivePlansJSON returns a SyncOptions.Archive func which writes each archived plan to w as a line of JSON.
+func ArchivePlansJSON(w io.Writer) func(plan PlanMetadata) error {
+	encoder := json.NewEncoder(w)
+
+	return func(plan PlanMetadata) error {
+		return encoder.Encode(plan)
+	}
+}
+
+// SyncPlans reconciles the desired plans with the plans that already exist on the suite. Plans are matched using the
+// alias and description, plans which match and are otherwise identical are left alone, plans which match and differ
+// are archived and recreated, and existing plans which do not match any desired plan are reported as orphans.
+func (c *APIClient) SyncPlans(desired []*PlanMetadata, opts SyncOptions) (result *SyncResult, err error) {
+	var existing []PlanMetadata
+
+	if existing, err = c.GetPlansAll(false, opts.Search); err != nil {
+		return nil, fmt.Errorf("error retrieving existing plans: %w", err)
+	}
+
+	result = &SyncResult{DryRun: opts.DryRun}
+
+	matched := make([]bool, len(existing))
+
+	var errs []error
+
+	for _, plan := range desired {
+		alias, description := planSyncKey(plan)
+
+		change := SyncChange{
+			Action:      SyncCreate,
+			Alias:       alias,
+			Description: description,
+			Desired:     plan,
+		}
+
+		for i := range existing {
+			if matched[i] {
+				continue
+			}
+
+			if a, d := planSyncKey(&existing[i]); a != alias || d != description {
+				continue
+			}
+
+			matched[i] = true
+
+			change.Existing = &existing[i]
+			change.ExistingID = existing[i].ID
+
+			if planSyncEqual(plan, &existing[i]) {
+				change.Action = SyncUnchanged
+			} else {
+				change.Action = SyncRecreate
+			}
+
+			break
+		}
+
+		if !opts.DryRun {
+			change.CreatedID, change.Err = c.applySyncChange(change, opts)
+
+			if change.Err != nil {
+				errs = append(errs, fmt.Errorf("error applying %s for plan '%s': %w", change.Action, alias, change.Err))
+			}
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	for i := range existing {
+		if matched[i] {
+			continue
+		}
+
+		alias, description := planSyncKey(&existing[i])
+
+		change := SyncChange{
+			Action:      SyncOrphan,
+			Alias:       alias,
+			Description: description,
+			ExistingID:  existing[i].ID,
+			Existing:    &existing[i],
+		}
+
+		if opts.DeleteOrphans {
+			change.Action = SyncDeleteOrphan
+
+			if !opts.DryRun {
+				if change.Err = c.archiveAndDeletePlan(existing[i], opts); change.Err != nil {
+					errs = append(errs, fmt.Errorf("error deleting orphaned plan '%s': %w", existing[i].ID, change.Err))
+				}
+			}
+		}
+
+		result.Changes = append(result.Changes, change)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (c *APIClient) applySyncChange(change SyncChange, opts SyncOptions) (id string, err error) {
+	switch change.Action {
+	case SyncUnchanged:
+		return "", nil
+	case SyncRecreate:
+		if err = c.archiveAndDeletePlan(*change.Existing, opts); err != nil {
+			return "", err
+		}
+	}
+
+	var response *PlanCreateResponse
+
+	if response, err = c.PostPlan(change.Desired); err != nil {
+		return "", err
+	}
+
+	return response.ID, nil
+}
+
+func (c *APIClient) archiveAndDeletePlan(plan PlanMetadata, opts SyncOptions) (err error) {
+	if opts.Archive != nil {
+		if err = opts.Archive(plan); err != nil {
+			return fmt.Errorf("error archiving plan '%s': %w", plan.ID, err)
+		}
+	}
+
+	var ok bool
+
+	if ok, err = c.DeletePlan(plan); err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("suite refused to delete plan '%s'", plan.ID)
+	}
+
+	return nil
+}
+
+func planSyncKey(plan *PlanMetadata) (alias, description string) {
+	if plan.Config == nil {
+		return "", plan.Description
+	}
+
+	return plan.Config.Alias, plan.Config.Description
+}
+
+// planSyncEqual compares the parts of a plan which are sent to the suite when it is created. The key material of the
+// client keys and certificates is ignored as it's generated each time a plan is built, only the type and algorithm of
+// the keys and whether the certificates are self-signed count.
+func planSyncEqual(a, b *PlanMetadata) bool {
+	return a.Name == b.Name && bytes.Equal(planSyncFingerprint(a.Variant), planSyncFingerprint(b.Variant)) && bytes.Equal(planSyncFingerprint(planSyncConfig(a.Config)), planSyncFingerprint(planSyncConfig(b.Config)))
+}
+
+func planSyncConfig(config *PlanConfig) *PlanConfig {
+	if config == nil {
+		return nil
+	}
+
+	c := *config
+
+	for _, client := range []**PlanClient{&c.Client, &c.Client2, &c.ClientSecretPost} {
+		if *client == nil || (*client).JWKS == nil {
+			continue
+		}
+
+		pc := **client
+
+		keys := make([]JSONWebKey, len(pc.JWKS.Keys))
+
+		for i, key := range pc.JWKS.Keys {
+			keys[i] = JSONWebKey{KeyType: key.KeyType, Algorithm: key.Algorithm, Curve: key.Curve}
+		}
+
+		pc.JWKS = &JSONWebKeySet{Keys: keys}
+
+		*client = &pc
+	}
+
+	for _, mtls := range []**PlanMTLS{&c.MTLS, &c.MTLS2} {
+		if *mtls == nil {
+			continue
+		}
+
+		m := &PlanMTLS{}
+
+		if !(*mtls).SelfSigned() {
+			m.CA = "ca"
+		}
+
+		*mtls = m
+	}
+
+	return &c
+}
+
+func planSyncFingerprint(v any) []byte {
+	data, _ := json.Marshal(v)
+
+	if bytes.Equal(data, []byte("null")) {
+		return []byte("{}")
+	}
+
+	return data
+}
@@ -0,0 +1,136 @@
+package oidcc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func newSyncTestPlans(t *testing.T) (desired []*PlanMetadata) {
+	t.Helper()
+
+	for _, alias := range []string{"basic", "hybrid", "implicit"} {
+		plan, err := NewCertificationProfileBasicDiscoveryPlan(alias, "Profile: "+alias, "secret", "https://idp.example.com", SummaryPublish)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		desired = append(desired, plan)
+	}
+
+	return desired
+}
+
+func TestSyncPlans(t *testing.T) {
+	suite := newFakeSuite(t)
+	client := suite.client()
+
+	desired := newSyncTestPlans(t)
+
+	basic := suite.add(*desired[0])
+
+	changed := *desired[1]
+	changed.Config = &PlanConfig{Alias: "hybrid", Description: "Profile: hybrid", Server: &PlanServer{DiscoveryURL: "https://old.example.com/.well-known/openid-configuration"}}
+
+	hybrid := suite.add(changed)
+	orphan := suite.add(PlanMetadata{Name: "oidcc-config-certification-test-plan", Config: &PlanConfig{Alias: "config", Description: "Profile: config"}})
+
+	result, err := client.SyncPlans(desired, SyncOptions{DryRun: true, DeleteOrphans: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(suite.creates) != 0 || len(suite.deletes) != 0 {
+		t.Fatalf("expected dry run to make no changes but got creates %v and deletes %v", suite.creates, suite.deletes)
+	}
+
+	expected := []SyncAction{SyncUnchanged, SyncRecreate, SyncCreate, SyncDeleteOrphan}
+
+	if len(result.Changes) != len(expected) {
+		t.Fatalf("expected %d changes but got %d", len(expected), len(result.Changes))
+	}
+
+	for i, change := range result.Changes {
+		if change.Action != expected[i] {
+			t.Errorf("change %d: expected action %s but got %s", i, expected[i], change.Action)
+		}
+	}
+
+	out := &bytes.Buffer{}
+
+	if err = result.Print(out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "DRY RUN") || !strings.Contains(out.String(), "recreate") {
+		t.Errorf("unexpected dry run output:\n%s", out.String())
+	}
+
+	archive := &bytes.Buffer{}
+
+	if result, err = client.SyncPlans(desired, SyncOptions{Archive: ArchivePlansJSON(archive)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Count(SyncOrphan) != 1 || result.Changes[3].ExistingID != orphan.ID {
+		t.Errorf("expected the config plan to be reported as an orphan")
+	}
+
+	if len(suite.deletes) != 1 || suite.deletes[0] != hybrid.ID {
+		t.Errorf("expected only the changed plan to be deleted but got %v", suite.deletes)
+	}
+
+	if len(suite.creates) != 2 {
+		t.Errorf("expected the changed and missing plans to be created but got %v", suite.creates)
+	}
+
+	if suite.plan(basic.ID) == nil {
+		t.Errorf("expected the unchanged plan to be left alone")
+	}
+
+	if !strings.Contains(archive.String(), hybrid.ID) {
+		t.Errorf("expected the changed plan to be archived but got %s", archive.String())
+	}
+
+	if result, err = client.SyncPlans(desired, SyncOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Count(SyncUnchanged) != 3 {
+		t.Errorf("expected every plan to be unchanged after a sync but got %d", result.Count(SyncUnchanged))
+	}
+}
+
+func TestPlanSyncEqualIgnoresKeyMaterial(t *testing.T) {
+	matrix := NewComprehensiveVariantMatrix("secret", "https://idp.example.com", NoPublish)
+	matrix.Include = nil
+
+	build := func(alg string) *PlanMetadata {
+		plan, err := matrix.Build("alias", "description", VariantCombination{"client_auth_type": "private_key_jwt", "response_type": "code", "response_mode": "default"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if alg != "" {
+			if err = plan.Config.Client.GenerateKey(alg); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return plan
+	}
+
+	a, b := build(""), build("")
+
+	if a.Config.Client.JWKS.Keys[0].D == b.Config.Client.JWKS.Keys[0].D {
+		t.Fatal("expected distinct keys")
+	}
+
+	if !planSyncEqual(a, b) {
+		t.Errorf("expected plans which only differ in key material to be equal")
+	}
+
+	if planSyncEqual(a, build("RS256")) {
+		t.Errorf("expected plans with different key algorithms to differ")
+	}
+}
@@ -0,0 +1,204 @@
+package oidcc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const DefaultTailInterval = 2 * time.Second
+
+// FollowTestLog incrementally fetches the log of a test instance by timestamp and calls fn with each new entry in the
+// order they were logged. Each poll fetches from one millisecond before the latest entry so entries logged in the same
+// millisecond after the previous poll aren't missed, and entries already delivered are skipped by ID. It returns once
+// the test instance has finished and its final entries have been delivered, when the context is cancelled, or when fn
+// returns an error.
+func (c *APIClient) FollowTestLog(ctx context.Context, testID string, interval time.Duration, fn func(entry LogEntry) error) (err error) {
+	if interval <= 0 {
+		interval = DefaultTailInterval
+	}
+
+	seen := map[string]bool{}
+
+	var latest int64
+
+	for {
+		var info *TestInfo
+
+		if info, err = c.GetTestInfo(ctx, testID); err != nil {
+			return err
+		}
+
+		var entries []LogEntry
+
+		if entries, err = c.GetTestLog(ctx, testID, max(latest-1, 0)); err != nil {
+			return err
+		}
+
+		previous := latest
+
+		for _, entry := range entries {
+			switch {
+			case entry.ID == "":
+				if previous != 0 && entry.Time <= previous {
+					continue
+				}
+			case seen[entry.ID]:
+				continue
+			default:
+				seen[entry.ID] = true
+			}
+
+			if entry.Time > latest {
+				latest = entry.Time
+			}
+
+			if err = fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if info.Status.Done() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// TailTestLog is the same as FollowTestLog except the entries are delivered on a channel. Both channels are closed once
+// following the log stops, and the error channel receives the error which stopped it if any.
+func (c *APIClient) TailTestLog(ctx context.Context, testID string, interval time.Duration) (<-chan LogEntry, <-chan error) {
+	entries, errs := make(chan LogEntry), make(chan error, 1)
+
+	go func() {
+		defer close(entries)
+		defer close(errs)
+
+		err := c.FollowTestLog(ctx, testID, interval, func(entry LogEntry) error {
+			select {
+			case entries <- entry:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return entries, errs
+}
+
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+)
+
+// LogFormatter renders log entries as single lines suitable for a terminal.
+type LogFormatter struct {
+	// Color enables ANSI colour codes for the result of each entry.
+	Color bool
+
+	// HTTP includes the method, URI, and status code of entries describing an HTTP exchange.
+	HTTP bool
+}
+
+func (f LogFormatter) Format(entry LogEntry) string {
+	b := &strings.Builder{}
+
+	b.WriteString(entry.Timestamp().Format("15:04:05.000"))
+	b.WriteString(" ")
+
+	if entry.StartBlock {
+		b.WriteString(f.colorize(ansiBold, "== "+entry.Message))
+
+		return b.String()
+	}
+
+	result := string(entry.Result)
+	if result == "" {
+		result = string(LogResultInfo)
+	}
+
+	b.WriteString(f.colorize(logResultColor(entry.Result), fmt.Sprintf("%-11s", result)))
+	b.WriteString(" ")
+
+	if entry.BlockID != "" {
+		b.WriteString("   ")
+	}
+
+	if entry.Source != "" {
+		b.WriteString(entry.Source)
+		b.WriteString(": ")
+	}
+
+	b.WriteString(entry.Message)
+
+	if len(entry.Requirements) != 0 {
+		b.WriteString(" [")
+		b.WriteString(strings.Join(entry.Requirements, ", "))
+		b.WriteString("]")
+	}
+
+	if exchange := entry.HTTPExchange(); f.HTTP && exchange != nil {
+		parts := []string{exchange.Direction}
+
+		for _, part := range []string{exchange.Method, exchange.URI, exchange.Path} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+
+		if exchange.StatusCode != 0 {
+			parts = append(parts, fmt.Sprint(exchange.StatusCode))
+		}
+
+		b.WriteString(" ")
+		b.WriteString(f.colorize(ansiCyan, "("+strings.Join(parts, " ")+")"))
+	}
+
+	return b.String()
+}
+
+func (f LogFormatter) Write(w io.Writer, entry LogEntry) (err error) {
+	_, err = fmt.Fprintln(w, f.Format(entry))
+
+	return err
+}
+
+func (f LogFormatter) colorize(color, s string) string {
+	if !f.Color || color == "" {
+		return s
+	}
+
+	return color + s + ansiReset
+}
+
+func logResultColor(result LogResult) string {
+	switch result {
+	case LogResultSuccess:
+		return ansiGreen
+	case LogResultFailure, LogResultInterrupted:
+		return ansiRed
+	case LogResultWarning:
+		return ansiYellow
+	case LogResultReview:
+		return ansiMagenta
+	default:
+		return ""
+	}
+}
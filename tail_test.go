@@ -0,0 +1,101 @@
+package oidcc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFollowTestLog(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	suite.setStatus("test-1", TestStatusRunning)
+	suite.log("test-1", LogEntry{Time: 1, Message: "one"}, LogEntry{Time: 2, Message: "two"})
+
+	var messages []string
+
+	err := suite.client().FollowTestLog(context.Background(), "test-1", time.Millisecond, func(entry LogEntry) error {
+		messages = append(messages, entry.Message)
+
+		if entry.Message == "two" {
+			suite.log("test-1", LogEntry{Time: 3, Message: "three"})
+			suite.setStatus("test-1", TestStatusFinished)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(messages, ",") != "one,two,three" {
+		t.Errorf("unexpected messages %v", messages)
+	}
+}
+
+func TestFollowTestLogSameTimestamp(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	suite.setStatus("test-1", TestStatusRunning)
+	suite.log("test-1", LogEntry{Time: 5, Message: "one"})
+
+	var messages []string
+
+	err := suite.client().FollowTestLog(context.Background(), "test-1", time.Millisecond, func(entry LogEntry) error {
+		messages = append(messages, entry.Message)
+
+		if entry.Message == "one" {
+			suite.log("test-1", LogEntry{Time: 5, Message: "two"})
+			suite.setStatus("test-1", TestStatusFinished)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(messages, ",") != "one,two" {
+		t.Errorf("expected the entry with the same timestamp from the next poll to be delivered once but got %v", messages)
+	}
+}
+
+func TestTailTestLogCancelled(t *testing.T) {
+	suite := newFakeSuite(t)
+
+	suite.setStatus("test-1", TestStatusWaiting)
+	suite.log("test-1", LogEntry{Time: 1, Message: "one"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	entries, errs := suite.client().TailTestLog(ctx, "test-1", time.Millisecond)
+
+	if entry := <-entries; entry.Message != "one" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	cancel()
+
+	for range entries {
+	}
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context to be cancelled but got %v", err)
+	}
+}
+
+func TestLogFormatter(t *testing.T) {
+	entry := LogEntry{Source: "CheckForUnexpectedParameters", Message: "No unexpected parameters", Result: LogResultSuccess, Requirements: []string{"OIDCC-3.1.2.1"}}
+
+	plain := LogFormatter{}.Format(entry)
+
+	if !strings.HasSuffix(plain, "SUCCESS     CheckForUnexpectedParameters: No unexpected parameters [OIDCC-3.1.2.1]") {
+		t.Errorf("unexpected plain output %q", plain)
+	}
+
+	if colored := (LogFormatter{Color: true}).Format(entry); !strings.Contains(colored, ansiGreen+"SUCCESS") {
+		t.Errorf("unexpected colored output %q", colored)
+	}
+}
@@ -0,0 +1,176 @@
+package oidcc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type PostPlansOptions struct {
+	// Atomic deletes every plan created by the call if any plan fails to be created.
+	Atomic bool
+
+	// Concurrency is the maximum number of plans created at the same time. Values less than 2 create the plans
+	// sequentially.
+	Concurrency int
+}
+
+// RollbackError is returned by PostPlansWithOptions when an atomic creation fails and the created plans are deleted.
+type RollbackError struct {
+	// Err is the joined errors of every plan which failed to be created.
+	Err error
+
+	// RolledBack is every plan created by the call which was successfully deleted.
+	RolledBack []*PlanCreateResponse
+
+	// Remaining is every plan created by the call which could not be deleted and still exists on the suite.
+	Remaining []*PlanCreateResponse
+
+	// RollbackErr is the joined errors of every plan which could not be deleted.
+	RollbackErr error
+}
+
+func (e *RollbackError) Error() string {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "plan creation failed and %d created plan(s) were rolled back", len(e.RolledBack))
+
+	if len(e.RolledBack) != 0 {
+		b.WriteString(" (")
+		b.WriteString(joinPlanCreateResponseIDs(e.RolledBack))
+		b.WriteString(")")
+	}
+
+	if len(e.Remaining) != 0 {
+		fmt.Fprintf(b, ", %d created plan(s) could not be rolled back (%s)", len(e.Remaining), joinPlanCreateResponseIDs(e.Remaining))
+	}
+
+	fmt.Fprintf(b, ": %v", e.Err)
+
+	if e.RollbackErr != nil {
+		fmt.Fprintf(b, ": rollback errors: %v", e.RollbackErr)
+	}
+
+	return b.String()
+}
+
+func (e *RollbackError) Unwrap() []error {
+	var errs []error
+
+	for _, err := range []error{e.Err, e.RollbackErr} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func joinPlanCreateResponseIDs(responses []*PlanCreateResponse) string {
+	ids := make([]string, len(responses))
+
+	for i, response := range responses {
+		ids[i] = response.ID
+	}
+
+	return strings.Join(ids, ", ")
+}
+
+// PostPlansWithOptions creates the plans and returns the responses of the plans which were created, in the same order
+// as the plans with the failed plans omitted, along with the joined errors of every plan which failed. When
+// opts.Atomic is set and any plan fails, every plan created by this call is deleted and a *RollbackError describing the
+// rollback is returned.
+func (c *APIClient) PostPlansWithOptions(opts PostPlansOptions, plans ...*PlanMetadata) (responses []*PlanCreateResponse, err error) {
+	results := make([]*PlanCreateResponse, len(plans))
+	errs := make([]error, len(plans))
+
+	var failed atomic.Bool
+
+	post := func(i int) {
+		if opts.Atomic && failed.Load() {
+			return
+		}
+
+		if results[i], errs[i] = c.PostPlan(plans[i]); errs[i] != nil {
+			errs[i] = fmt.Errorf("error creating plan '%s': %w", planDisplayName(plans[i]), errs[i])
+
+			failed.Store(true)
+		}
+	}
+
+	if opts.Concurrency < 2 {
+		for i := range plans {
+			post(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+
+		semaphore := make(chan struct{}, opts.Concurrency)
+
+		for i := range plans {
+			wg.Add(1)
+
+			semaphore <- struct{}{}
+
+			go func(i int) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+
+				post(i)
+			}(i)
+		}
+
+		wg.Wait()
+	}
+
+	for _, result := range results {
+		if result != nil {
+			responses = append(responses, result)
+		}
+	}
+
+	if err = errors.Join(errs...); err == nil || !opts.Atomic {
+		return responses, err
+	}
+
+	return nil, c.rollbackPlans(err, responses)
+}
+
+func (c *APIClient) rollbackPlans(cause error, created []*PlanCreateResponse) *RollbackError {
+	rollback := &RollbackError{Err: cause}
+
+	var errs []error
+
+	for _, response := range created {
+		ok, err := c.DeletePlan(PlanMetadata{ID: response.ID})
+
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("error deleting plan '%s': %w", response.ID, err))
+		case !ok:
+			errs = append(errs, fmt.Errorf("suite refused to delete plan '%s'", response.ID))
+		default:
+			rollback.RolledBack = append(rollback.RolledBack, response)
+
+			continue
+		}
+
+		rollback.Remaining = append(rollback.Remaining, response)
+	}
+
+	rollback.RollbackErr = errors.Join(errs...)
+
+	return rollback
+}
+
+func planDisplayName(plan *PlanMetadata) string {
+	if plan.Config != nil && plan.Config.Alias != "" {
+		return plan.Config.Alias
+	}
+
+	return plan.Name
+}
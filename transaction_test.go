@@ -0,0 +1,83 @@
+package oidcc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPostPlansWithOptionsAtomicRollback(t *testing.T) {
+	suite := newFakeSuite(t)
+	client := suite.client()
+
+	plans := newSyncTestPlans(t)
+
+	suite.failCreate["hybrid"] = true
+
+	responses, err := client.PostPlansWithOptions(PostPlansOptions{Atomic: true}, plans...)
+	if responses != nil {
+		t.Errorf("expected no responses but got %v", responses)
+	}
+
+	var rollback *RollbackError
+
+	if !errors.As(err, &rollback) {
+		t.Fatalf("expected a rollback error but got %v", err)
+	}
+
+	if len(rollback.RolledBack) != 1 || rollback.RolledBack[0].ID != suite.creates[0] || len(rollback.Remaining) != 0 {
+		t.Errorf("expected only the first plan to be rolled back but got %+v", rollback)
+	}
+
+	if !strings.Contains(err.Error(), "hybrid") || !strings.Contains(err.Error(), suite.creates[0]) {
+		t.Errorf("expected the error to describe the failure and rollback but got %v", err)
+	}
+
+	if len(suite.plans) != 0 {
+		t.Errorf("expected no plans to remain on the suite but got %d", len(suite.plans))
+	}
+}
+
+func TestPostPlansWithOptionsConcurrent(t *testing.T) {
+	suite := newFakeSuite(t)
+	client := suite.client()
+
+	plans, err := NewComprehensiveDiscoveryPlanAll("secret", "https://idp.example.com", SummaryPublish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite.failCreate[plans[3].Config.Alias] = true
+	suite.failCreate[plans[7].Config.Alias] = true
+
+	responses, err := client.PostPlansWithOptions(PostPlansOptions{Concurrency: 4}, plans...)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, i := range []int{3, 7} {
+		if !strings.Contains(err.Error(), plans[i].Config.Alias) {
+			t.Errorf("expected the error to include plan '%s' but got %v", plans[i].Config.Alias, err)
+		}
+	}
+
+	if len(responses) != len(plans)-2 || len(suite.plans) != len(plans)-2 {
+		t.Fatalf("expected %d plans to be created but got %d", len(plans)-2, len(responses))
+	}
+
+	for i, response := range responses {
+		j := i
+
+		if i >= 3 {
+			j++
+		}
+
+		if i >= 6 {
+			j++
+		}
+
+		if created := suite.plan(response.ID); created == nil || created.Config.Alias != plans[j].Config.Alias {
+			t.Errorf("response %d is not in plan order", i)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package oidcc
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TriageRule maps a failing log entry to a probable cause and a suggested fix. An entry matches the rule when the
+// Source matches the condition name and the Pattern matches the message or the error details of the entry. A nil
+// Source or Pattern matches every entry.
+type TriageRule struct {
+	Name    string
+	Source  *regexp.Regexp
+	Pattern *regexp.Regexp
+	Cause   string
+	Fix     string
+}
+
+func (r TriageRule) Matches(entry LogEntry) bool {
+	if r.Source != nil && !r.Source.MatchString(entry.Source) {
+		return false
+	}
+
+	if r.Pattern != nil && !r.Pattern.MatchString(triageText(entry)) {
+		return false
+	}
+
+	return true
+}
+
+type TriageFinding struct {
+	Rule  string   `json:"rule"`
+	Cause string   `json:"cause"`
+	Fix   string   `json:"fix"`
+	Entry LogEntry `json:"entry"`
+}
+
+func (f TriageFinding) String() string {
+	return f.Cause + ": " + f.Fix
+}
+
+// Triager applies the triage rules in order to the failing entries of a test log.
+type Triager struct {
+	Rules []TriageRule
+}
+
+// NewTriager returns a Triager with the DefaultTriageRules followed by any additional rules.
+func NewTriager(rules ...TriageRule) *Triager {
+	return &Triager{Rules: append(DefaultTriageRules(), rules...)}
+}
+
+// Triage returns a finding for each FAILURE or WARNING entry which matches a rule using the first matching rule.
+func (t *Triager) Triage(entries []LogEntry) (findings []TriageFinding) {
+	for _, entry := range entries {
+		if entry.Result != LogResultFailure && entry.Result != LogResultWarning {
+			continue
+		}
+
+		for _, rule := range t.Rules {
+			if rule.Matches(entry) {
+				findings = append(findings, TriageFinding{Rule: rule.Name, Cause: rule.Cause, Fix: rule.Fix, Entry: entry})
+
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// ProbableCause returns the finding for the first failure in the log, or the first warning if there are no failures
+// with a finding.
+func (t *Triager) ProbableCause(entries []LogEntry) *TriageFinding {
+	findings := t.Triage(entries)
+
+	for _, finding := range findings {
+		if finding.Entry.Result == LogResultFailure {
+			return &finding
+		}
+	}
+
+	if len(findings) != 0 {
+		return &findings[0]
+	}
+
+	return nil
+}
+
+func triageText(entry LogEntry) string {
+	parts := []string{entry.Message}
+
+	for _, key := range []string{"error", "error_description", "expected", "actual"} {
+		if value := entry.String(key); value != "" {
+			parts = append(parts, value)
+		}
+	}
+
+	if exchange := entry.HTTPExchange(); exchange != nil && exchange.Body != "" {
+		parts = append(parts, exchange.Body)
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+func DefaultTriageRules() []TriageRule {
+	return []TriageRule{
+		{
+			Name:    "redirect-uri-mismatch",
+			Pattern: regexp.MustCompile(`(?i)redirect[_ ]uri.*(mismatch|not (registered|allowed|whitelisted)|does not match)|(mismatch|unregistered).*redirect[_ ]uri`),
+			Cause:   "The redirect URI the suite used is not registered for the client",
+			Fix:     "add the suite callback https://<suite>/test/a/<alias>/callback to redirect_uris of the client",
+		},
+		{
+			Name:    "unsupported-response-type",
+			Pattern: regexp.MustCompile(`(?i)unsupported_response_type|response[_ ]type.*not (allowed|permitted|supported)|unauthorized_client.*response`),
+			Cause:   "The client is not permitted to use the response type the plan requested",
+			Fix:     "add the response type of the plan variant to response_types and the grant types it implies to grant_types",
+		},
+		{
+			Name:    "unsupported-response-mode",
+			Pattern: regexp.MustCompile(`(?i)unsupported_response_mode|response[_ ]mode.*not (allowed|permitted|supported)`),
+			Cause:   "The client is not permitted to use the response mode the plan requested",
+			Fix:     "add the response mode of the plan variant to response_modes of the client",
+		},
+		{
+			Name:    "unsupported-grant-type",
+			Pattern: regexp.MustCompile(`(?i)unsupported_grant_type|unauthorized_client.*grant|grant[_ ]type.*not (allowed|permitted)`),
+			Cause:   "The client is not permitted to use the grant type the suite used",
+			Fix:     "add authorization_code, refresh_token, or implicit to grant_types as required by the response types",
+		},
+		{
+			Name:    "invalid-client-auth",
+			Pattern: regexp.MustCompile(`(?i)invalid_client|client authentication failed|token_endpoint_auth_method`),
+			Cause:   "The token endpoint rejected the client authentication",
+			Fix:     "set token_endpoint_auth_method to match the client_auth_type of the plan variant and ensure the client_secret matches the plan",
+		},
+		{
+			Name:    "invalid-scope",
+			Pattern: regexp.MustCompile(`(?i)invalid_scope|scope.*not (allowed|permitted)`),
+			Cause:   "The client is not permitted a scope the suite requested",
+			Fix:     "add openid, offline_access, profile, email, phone, and address to scopes of the client",
+		},
+		{
+			Name:    "request-object-alg",
+			Pattern: regexp.MustCompile(`(?i)invalid_request_object|request_object_signing_alg|request object.*(alg|signature)`),
+			Cause:   "The request object signing algorithm the suite used is not permitted for the client",
+			Fix:     "set request_object_signing_alg of the client to the algorithm the plan uses or to none for unsigned request objects",
+		},
+		{
+			Name:    "interaction-required",
+			Pattern: regexp.MustCompile(`(?i)consent_required|login_required|interaction_required`),
+			Cause:   "The provider required user interaction the suite did not expect",
+			Fix:     "set consent_mode to implicit and authorization_policy to one_factor for the client",
+		},
+	}
+}
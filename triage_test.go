@@ -0,0 +1,80 @@
+package oidcc
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestTriagerDefaultRules(t *testing.T) {
+	testCases := []struct {
+		name  string
+		entry LogEntry
+		rule  string
+	}{
+		{
+			"ShouldMatchRedirectURIMismatch",
+			LogEntry{Result: LogResultFailure, Message: "The redirect_uri does not match any of the registered redirect_uris"},
+			"redirect-uri-mismatch",
+		},
+		{
+			"ShouldMatchUnsupportedResponseTypeInErrorField",
+			LogEntry{Result: LogResultFailure, Message: "Error from the authorization endpoint", Extra: map[string]json.RawMessage{"error": json.RawMessage(`"unsupported_response_type"`)}},
+			"unsupported-response-type",
+		},
+		{
+			"ShouldMatchInvalidClientInResponseBody",
+			LogEntry{Result: LogResultFailure, Source: "CheckTokenEndpointHttpStatus200", HTTP: "response", Extra: map[string]json.RawMessage{"response_body": json.RawMessage(`"{\"error\":\"invalid_client\"}"`)}},
+			"invalid-client-auth",
+		},
+		{
+			"ShouldNotMatchSuccess",
+			LogEntry{Result: LogResultSuccess, Message: "invalid_client"},
+			"",
+		},
+		{
+			"ShouldNotMatchUnknownFailure",
+			LogEntry{Result: LogResultFailure, Message: "nonce mismatch"},
+			"",
+		},
+	}
+
+	triager := NewTriager()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings := triager.Triage([]LogEntry{tc.entry})
+
+			switch {
+			case tc.rule == "" && len(findings) != 0:
+				t.Errorf("expected no findings but got %+v", findings)
+			case tc.rule != "" && (len(findings) != 1 || findings[0].Rule != tc.rule):
+				t.Errorf("expected rule '%s' but got %+v", tc.rule, findings)
+			}
+		})
+	}
+}
+
+func TestTriagerCustomRuleAndProbableCause(t *testing.T) {
+	triager := NewTriager(TriageRule{
+		Name:    "nonce",
+		Source:  regexp.MustCompile(`^ValidateIdTokenNonce$`),
+		Pattern: regexp.MustCompile(`nonce`),
+		Cause:   "The nonce was not returned",
+		Fix:     "return the nonce",
+	})
+
+	entries := []LogEntry{
+		{Result: LogResultWarning, Message: "invalid_scope returned"},
+		{Result: LogResultFailure, Source: "ValidateIdTokenNonce", Message: "nonce mismatch"},
+	}
+
+	cause := triager.ProbableCause(entries)
+	if cause == nil || cause.Rule != "nonce" {
+		t.Fatalf("expected the failure to be the probable cause but got %+v", cause)
+	}
+
+	if cause = triager.ProbableCause(entries[:1]); cause == nil || cause.Rule != "invalid-scope" {
+		t.Errorf("expected the warning to be the probable cause but got %+v", cause)
+	}
+}